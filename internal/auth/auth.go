@@ -28,6 +28,7 @@ type User struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Username  string    `json:"username"`
+	IsAdmin   bool      `json:"is_admin"`
 }
 
 func (a *AuthService) GetAuthenticatedUser(r *http.Request) (*User, error) {
@@ -44,7 +45,7 @@ func (a *AuthService) GetAuthenticatedUser(r *http.Request) (*User, error) {
 		return nil, errors.New("user not in database")
 	}
 
-	user := User{ID: dbUser.ID, CreatedAt: dbUser.CreatedAt, UpdatedAt: dbUser.UpdatedAt, Username: dbUser.Username}
+	user := User{ID: dbUser.ID, CreatedAt: dbUser.CreatedAt, UpdatedAt: dbUser.UpdatedAt, Username: dbUser.Username, IsAdmin: dbUser.IsAdmin}
 	return &user, nil
 
 }
@@ -7,31 +7,292 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createLanguage = `-- name: CreateLanguage :one
-INSERT INTO languages(id, name)
-VALUES(gen_random_uuid(), $1)
-returning id, name
+INSERT INTO languages(id, name, display_name, extensions, mime_type, line_comment, block_comment_start, block_comment_end)
+VALUES(gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7)
+returning id, name, display_name, extensions, mime_type, line_comment, block_comment_start, block_comment_end
 `
 
-func (q *Queries) CreateLanguage(ctx context.Context, name string) (Language, error) {
-	row := q.db.QueryRowContext(ctx, createLanguage, name)
+type CreateLanguageParams struct {
+	Name              string
+	DisplayName       string
+	Extensions        []string
+	MimeType          string
+	LineComment       string
+	BlockCommentStart string
+	BlockCommentEnd   string
+}
+
+func (q *Queries) CreateLanguage(ctx context.Context, arg CreateLanguageParams) (Language, error) {
+	row := q.db.QueryRowContext(ctx, createLanguage,
+		arg.Name,
+		arg.DisplayName,
+		pq.Array(arg.Extensions),
+		arg.MimeType,
+		arg.LineComment,
+		arg.BlockCommentStart,
+		arg.BlockCommentEnd,
+	)
 	var i Language
-	err := row.Scan(&i.ID, &i.Name)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.DisplayName,
+		pq.Array(&i.Extensions),
+		&i.MimeType,
+		&i.LineComment,
+		&i.BlockCommentStart,
+		&i.BlockCommentEnd,
+	)
 	return i, err
 }
 
+const deleteLanguage = `-- name: DeleteLanguage :execrows
+DELETE FROM languages
+WHERE languages.id = $1
+AND NOT EXISTS (SELECT 1 FROM snippets WHERE snippets.language_id = languages.id)
+AND NOT EXISTS (SELECT 1 FROM snippet_files WHERE snippet_files.language_id = languages.id)
+AND NOT EXISTS (SELECT 1 FROM snippet_revisions WHERE snippet_revisions.language_id = languages.id)
+`
+
+func (q *Queries) DeleteLanguage(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteLanguage, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteLanguageAliases = `-- name: DeleteLanguageAliases :exec
+DELETE FROM language_aliases WHERE language_aliases.language_id = $1
+`
+
+func (q *Queries) DeleteLanguageAliases(ctx context.Context, languageID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteLanguageAliases, languageID)
+	return err
+}
+
+const getLanguageAliases = `-- name: GetLanguageAliases :many
+SELECT language_aliases.alias
+FROM language_aliases
+INNER JOIN languages ON languages.id = language_aliases.language_id
+WHERE language_aliases.language_id = $1
+AND language_aliases.alias <> lower(languages.name)
+ORDER BY language_aliases.alias
+`
+
+func (q *Queries) GetLanguageAliases(ctx context.Context, languageID uuid.UUID) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getLanguageAliases, languageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, err
+		}
+		items = append(items, alias)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getLanguageByName = `-- name: GetLanguageByName :one
-SELECT id FROM languages
-WHERE name = $1
+SELECT languages.id, languages.name, languages.display_name, languages.extensions, languages.mime_type, languages.line_comment, languages.block_comment_start, languages.block_comment_end FROM languages
+INNER JOIN language_aliases ON language_aliases.language_id = languages.id
+WHERE language_aliases.alias = lower($1)
 `
 
-func (q *Queries) GetLanguageByName(ctx context.Context, name string) (uuid.UUID, error) {
+func (q *Queries) GetLanguageByName(ctx context.Context, name string) (Language, error) {
 	row := q.db.QueryRowContext(ctx, getLanguageByName, name)
-	var id uuid.UUID
-	err := row.Scan(&id)
-	return id, err
+	var i Language
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.DisplayName,
+		pq.Array(&i.Extensions),
+		&i.MimeType,
+		&i.LineComment,
+		&i.BlockCommentStart,
+		&i.BlockCommentEnd,
+	)
+	return i, err
+}
+
+const getLanguageSamples = `-- name: GetLanguageSamples :many
+SELECT languages.name AS language, samples.file_text
+FROM languages
+CROSS JOIN LATERAL (
+    SELECT left(snippet_files.file_text, $1::int) AS file_text
+    FROM snippet_files
+    INNER JOIN snippets ON snippets.id = snippet_files.snippet_id
+    WHERE snippet_files.language_id = languages.id AND snippets.visibility = 'public'
+    AND NOT snippet_files.language_detected
+    ORDER BY snippets.created_at DESC
+    LIMIT $2
+) AS samples
+`
+
+type GetLanguageSamplesParams struct {
+	MaxLength   int32
+	PerLanguage int32
+}
+
+type GetLanguageSamplesRow struct {
+	Language string
+	FileText string
+}
+
+func (q *Queries) GetLanguageSamples(ctx context.Context, arg GetLanguageSamplesParams) ([]GetLanguageSamplesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getLanguageSamples, arg.MaxLength, arg.PerLanguage)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLanguageSamplesRow
+	for rows.Next() {
+		var i GetLanguageSamplesRow
+		if err := rows.Scan(&i.Language, &i.FileText); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLanguages = `-- name: GetLanguages :many
+SELECT languages.id, languages.name, languages.display_name, languages.extensions, languages.mime_type, languages.line_comment, languages.block_comment_start, languages.block_comment_end,
+ COALESCE((SELECT array_agg(language_aliases.alias ORDER BY language_aliases.alias) FROM language_aliases WHERE language_aliases.language_id = languages.id AND language_aliases.alias <> lower(languages.name)), '{}')::text[] AS aliases,
+ COUNT(snippets.id) AS snippet_count,
+ MAX(snippets.created_at)::timestamp AS latest_snippet_at
+FROM languages
+LEFT JOIN snippets ON snippets.language_id = languages.id AND snippets.visibility = 'public'
+GROUP BY languages.id
+ORDER BY languages.name
+`
+
+type GetLanguagesRow struct {
+	ID                uuid.UUID
+	Name              string
+	DisplayName       string
+	Extensions        []string
+	MimeType          string
+	LineComment       string
+	BlockCommentStart string
+	BlockCommentEnd   string
+	Aliases           []string
+	SnippetCount      int64
+	LatestSnippetAt   sql.NullTime
+}
+
+func (q *Queries) GetLanguages(ctx context.Context) ([]GetLanguagesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getLanguages)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLanguagesRow
+	for rows.Next() {
+		var i GetLanguagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.DisplayName,
+			pq.Array(&i.Extensions),
+			&i.MimeType,
+			&i.LineComment,
+			&i.BlockCommentStart,
+			&i.BlockCommentEnd,
+			pq.Array(&i.Aliases),
+			&i.SnippetCount,
+			&i.LatestSnippetAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setLanguageAliases = `-- name: SetLanguageAliases :exec
+INSERT INTO language_aliases(alias, language_id)
+SELECT new_aliases.alias, $1
+FROM unnest($2::text[]) AS new_aliases(alias)
+`
+
+type SetLanguageAliasesParams struct {
+	LanguageID uuid.UUID
+	Aliases    []string
+}
+
+func (q *Queries) SetLanguageAliases(ctx context.Context, arg SetLanguageAliasesParams) error {
+	_, err := q.db.ExecContext(ctx, setLanguageAliases, arg.LanguageID, pq.Array(arg.Aliases))
+	return err
+}
+
+const updateLanguage = `-- name: UpdateLanguage :one
+UPDATE languages SET name = $2, display_name = $3, extensions = $4, mime_type = $5, line_comment = $6, block_comment_start = $7, block_comment_end = $8
+WHERE languages.id = $1
+RETURNING id, name, display_name, extensions, mime_type, line_comment, block_comment_start, block_comment_end
+`
+
+type UpdateLanguageParams struct {
+	ID                uuid.UUID
+	Name              string
+	DisplayName       string
+	Extensions        []string
+	MimeType          string
+	LineComment       string
+	BlockCommentStart string
+	BlockCommentEnd   string
+}
+
+func (q *Queries) UpdateLanguage(ctx context.Context, arg UpdateLanguageParams) (Language, error) {
+	row := q.db.QueryRowContext(ctx, updateLanguage,
+		arg.ID,
+		arg.Name,
+		arg.DisplayName,
+		pq.Array(arg.Extensions),
+		arg.MimeType,
+		arg.LineComment,
+		arg.BlockCommentStart,
+		arg.BlockCommentEnd,
+	)
+	var i Language
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.DisplayName,
+		pq.Array(&i.Extensions),
+		&i.MimeType,
+		&i.LineComment,
+		&i.BlockCommentStart,
+		&i.BlockCommentEnd,
+	)
+	return i, err
 }
@@ -6,14 +6,43 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type Collection struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	Name        string
+	Description string
+	Visibility  string
+}
+
+type CollectionSnippet struct {
+	CollectionID uuid.UUID
+	SnippetID    uuid.UUID
+	Position     int32
+	AddedAt      time.Time
+}
+
 type Language struct {
-	ID   uuid.UUID
-	Name string
+	ID                uuid.UUID
+	Name              string
+	DisplayName       string
+	Extensions        []string
+	MimeType          string
+	LineComment       string
+	BlockCommentStart string
+	BlockCommentEnd   string
+}
+
+type LanguageAlias struct {
+	Alias      string
+	LanguageID uuid.UUID
 }
 
 type RefreshToken struct {
@@ -25,6 +54,18 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type SavedSearch struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	UserID        uuid.UUID
+	Name          string
+	Query         string
+	LastCheckedAt time.Time
+	NewCount      int32
+	CountedAt     sql.NullTime
+}
+
 type Snippet struct {
 	ID                 uuid.UUID
 	CreatedAt          time.Time
@@ -35,6 +76,73 @@ type Snippet struct {
 	SnippetDescription string
 	SnippetText        string
 	SearchVector       interface{}
+	Visibility         string
+	ForkedFromID       uuid.NullUUID
+	StarCount          int32
+	CodeVector         interface{}
+	ForkCount          int32
+	ViewCount          int32
+}
+
+type SnippetComment struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	SnippetID        uuid.UUID
+	UserID           uuid.UUID
+	ParentID         uuid.NullUUID
+	Body             string
+	DeletedAt        sql.NullTime
+	DeletedBy        uuid.NullUUID
+	Revision         sql.NullInt32
+	FileName         sql.NullString
+	StartLine        sql.NullInt32
+	EndLine          sql.NullInt32
+	CurrentStartLine sql.NullInt32
+	CurrentEndLine   sql.NullInt32
+	Outdated         bool
+}
+
+type SnippetFile struct {
+	ID               uuid.UUID
+	SnippetID        uuid.UUID
+	Position         int32
+	FileName         string
+	LanguageID       uuid.UUID
+	FileText         string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	LanguageDetected bool
+}
+
+type SnippetRevision struct {
+	ID                 uuid.UUID
+	SnippetID          uuid.UUID
+	Revision           int32
+	CreatedAt          time.Time
+	UserID             uuid.UUID
+	LanguageID         uuid.UUID
+	SnippetTitle       string
+	SnippetDescription string
+	SnippetText        string
+	Files              json.RawMessage
+}
+
+type SnippetStar struct {
+	UserID    uuid.UUID
+	SnippetID uuid.UUID
+	CreatedAt time.Time
+}
+
+type SnippetTag struct {
+	SnippetID uuid.UUID
+	TagID     uuid.UUID
+}
+
+type Tag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Name      string
 }
 
 type User struct {
@@ -43,4 +151,5 @@ type User struct {
 	UpdatedAt      time.Time
 	Username       string
 	HashedPassword string
+	IsAdmin        bool
 }
@@ -11,15 +11,90 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const addSnippetViewCounts = `-- name: AddSnippetViewCounts :exec
+UPDATE snippets SET view_count = snippets.view_count + counts.views
+FROM unnest($1::text[], $2::int[]) AS counts(snippet_id, views)
+WHERE snippets.id = counts.snippet_id::uuid
+`
+
+type AddSnippetViewCountsParams struct {
+	SnippetIds []string
+	Views      []int32
+}
+
+func (q *Queries) AddSnippetViewCounts(ctx context.Context, arg AddSnippetViewCountsParams) error {
+	_, err := q.db.ExecContext(ctx, addSnippetViewCounts, pq.Array(arg.SnippetIds), pq.Array(arg.Views))
+	return err
+}
+
+const countSnippets = `-- name: CountSnippets :one
+SELECT COUNT(*) FROM filter_snippets(
+    $1,
+    $2::text[],
+    $3::text[],
+    $4::timestamp,
+    $5::timestamp,
+    $6::timestamp,
+    $7::boolean,
+    $8::int,
+    $9::tsquery,
+    $10::text,
+    $11::text,
+    $12::text,
+    $13::text[],
+    $14::boolean
+) AS filtered
+`
+
+type CountSnippetsParams struct {
+	OwnerID       uuid.NullUUID
+	Languages     []string
+	Usernames     []string
+	CreatedAfter  sql.NullTime
+	CreatedBefore sql.NullTime
+	UpdatedSince  sql.NullTime
+	HasForks      sql.NullBool
+	MinStars      sql.NullInt32
+	Search        sql.NullString
+	Fuzzy         sql.NullString
+	Substring     sql.NullString
+	RegexLiteral  sql.NullString
+	Tags          []string
+	MatchAllTags  bool
+}
+
+func (q *Queries) CountSnippets(ctx context.Context, arg CountSnippetsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSnippets,
+		arg.OwnerID,
+		pq.Array(arg.Languages),
+		pq.Array(arg.Usernames),
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.UpdatedSince,
+		arg.HasForks,
+		arg.MinStars,
+		arg.Search,
+		arg.Fuzzy,
+		arg.Substring,
+		arg.RegexLiteral,
+		pq.Array(arg.Tags),
+		arg.MatchAllTags,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createSnippet = `-- name: CreateSnippet :one
 WITH inserted_snippet AS (
-INSERT INTO snippets(id, created_at, updated_at, language_id, user_id, snippet_text, snippet_description, snippet_title)
-VALUES(gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, $5)
-RETURNING id, created_at, updated_at, language_id, user_id, snippet_title, snippet_description, snippet_text, search_vector
+INSERT INTO snippets(id, created_at, updated_at, language_id, user_id, snippet_text, snippet_description, snippet_title, visibility)
+VALUES(gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, $5, $6)
+RETURNING id, created_at, updated_at, language_id, user_id, snippet_title, snippet_description, snippet_text, search_vector, visibility, forked_from_id, star_count, code_vector, fork_count, view_count
 )
-SELECT inserted_snippet.id, inserted_snippet.created_at, inserted_snippet.updated_at, inserted_snippet.language_id, inserted_snippet.user_id, inserted_snippet.snippet_title, inserted_snippet.snippet_description, inserted_snippet.snippet_text, inserted_snippet.search_vector, users.username
+SELECT inserted_snippet.id, inserted_snippet.created_at, inserted_snippet.updated_at, inserted_snippet.language_id, inserted_snippet.user_id, inserted_snippet.snippet_title, inserted_snippet.snippet_description, inserted_snippet.snippet_text, inserted_snippet.search_vector, inserted_snippet.visibility, inserted_snippet.forked_from_id, inserted_snippet.star_count, inserted_snippet.code_vector, inserted_snippet.fork_count, inserted_snippet.view_count, users.username
 FROM inserted_snippet
 INNER JOIN users ON users.id = inserted_snippet.user_id
 `
@@ -30,6 +105,7 @@ type CreateSnippetParams struct {
 	SnippetText        string
 	SnippetDescription string
 	SnippetTitle       string
+	Visibility         string
 }
 
 type CreateSnippetRow struct {
@@ -42,6 +118,12 @@ type CreateSnippetRow struct {
 	SnippetDescription string
 	SnippetText        string
 	SearchVector       interface{}
+	Visibility         string
+	ForkedFromID       uuid.NullUUID
+	StarCount          int32
+	CodeVector         interface{}
+	ForkCount          int32
+	ViewCount          int32
 	Username           string
 }
 
@@ -52,6 +134,7 @@ func (q *Queries) CreateSnippet(ctx context.Context, arg CreateSnippetParams) (C
 		arg.SnippetText,
 		arg.SnippetDescription,
 		arg.SnippetTitle,
+		arg.Visibility,
 	)
 	var i CreateSnippetRow
 	err := row.Scan(
@@ -64,6 +147,12 @@ func (q *Queries) CreateSnippet(ctx context.Context, arg CreateSnippetParams) (C
 		&i.SnippetDescription,
 		&i.SnippetText,
 		&i.SearchVector,
+		&i.Visibility,
+		&i.ForkedFromID,
+		&i.StarCount,
+		&i.CodeVector,
+		&i.ForkCount,
+		&i.ViewCount,
 		&i.Username,
 	)
 	return i, err
@@ -78,8 +167,193 @@ func (q *Queries) DeleteSnippetById(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const forkSnippet = `-- name: ForkSnippet :one
+WITH inserted_snippet AS (
+INSERT INTO snippets(id, created_at, updated_at, language_id, user_id, snippet_text, snippet_description, snippet_title, visibility, forked_from_id)
+SELECT gen_random_uuid(), NOW(), NOW(), snippets.language_id, $1, snippets.snippet_text, snippets.snippet_description, snippets.snippet_title, snippets.visibility, snippets.id
+FROM snippets
+WHERE snippets.id = $2
+RETURNING id, created_at, updated_at, language_id, user_id, snippet_title, snippet_description, snippet_text, search_vector, visibility, forked_from_id, star_count, code_vector, fork_count, view_count
+)
+SELECT inserted_snippet.id, inserted_snippet.created_at, inserted_snippet.updated_at, inserted_snippet.language_id, inserted_snippet.user_id, inserted_snippet.snippet_title, inserted_snippet.snippet_description, inserted_snippet.snippet_text, inserted_snippet.search_vector, inserted_snippet.visibility, inserted_snippet.forked_from_id, inserted_snippet.star_count, inserted_snippet.code_vector, inserted_snippet.fork_count, inserted_snippet.view_count, users.username, languages.name AS language
+FROM inserted_snippet
+INNER JOIN users ON users.id = inserted_snippet.user_id
+INNER JOIN languages ON languages.id = inserted_snippet.language_id
+`
+
+type ForkSnippetParams struct {
+	UserID uuid.UUID
+	ID     uuid.UUID
+}
+
+type ForkSnippetRow struct {
+	ID                 uuid.UUID
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	LanguageID         uuid.UUID
+	UserID             uuid.UUID
+	SnippetTitle       string
+	SnippetDescription string
+	SnippetText        string
+	SearchVector       interface{}
+	Visibility         string
+	ForkedFromID       uuid.NullUUID
+	StarCount          int32
+	CodeVector         interface{}
+	ForkCount          int32
+	ViewCount          int32
+	Username           string
+	Language           string
+}
+
+func (q *Queries) ForkSnippet(ctx context.Context, arg ForkSnippetParams) (ForkSnippetRow, error) {
+	row := q.db.QueryRowContext(ctx, forkSnippet, arg.UserID, arg.ID)
+	var i ForkSnippetRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LanguageID,
+		&i.UserID,
+		&i.SnippetTitle,
+		&i.SnippetDescription,
+		&i.SnippetText,
+		&i.SearchVector,
+		&i.Visibility,
+		&i.ForkedFromID,
+		&i.StarCount,
+		&i.CodeVector,
+		&i.ForkCount,
+		&i.ViewCount,
+		&i.Username,
+		&i.Language,
+	)
+	return i, err
+}
+
+const getClosestLexeme = `-- name: GetClosestLexeme :one
+SELECT snippet_lexemes.word
+FROM snippet_lexemes
+WHERE snippet_lexemes.word % $1::text
+ORDER BY similarity(snippet_lexemes.word, $1::text) DESC, snippet_lexemes.ndoc DESC
+LIMIT 1
+`
+
+func (q *Queries) GetClosestLexeme(ctx context.Context, query string) (string, error) {
+	row := q.db.QueryRowContext(ctx, getClosestLexeme, query)
+	var word string
+	err := row.Scan(&word)
+	return word, err
+}
+
+const getPublicSnippetTerms = `-- name: GetPublicSnippetTerms :many
+SELECT snippets.id, snippets.snippet_title, users.username, languages.name AS language,
+ COALESCE((SELECT array_agg(tags.name ORDER BY tags.name) FROM tags INNER JOIN snippet_tags ON snippet_tags.tag_id = tags.id WHERE snippet_tags.snippet_id = snippets.id), '{}')::text[] AS tags
+FROM snippets
+INNER JOIN users ON users.id = snippets.user_id
+INNER JOIN languages ON languages.id = snippets.language_id
+WHERE snippets.visibility = 'public'
+`
+
+type GetPublicSnippetTermsRow struct {
+	ID           uuid.UUID
+	SnippetTitle string
+	Username     string
+	Language     string
+	Tags         []string
+}
+
+func (q *Queries) GetPublicSnippetTerms(ctx context.Context) ([]GetPublicSnippetTermsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPublicSnippetTerms)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPublicSnippetTermsRow
+	for rows.Next() {
+		var i GetPublicSnippetTermsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SnippetTitle,
+			&i.Username,
+			&i.Language,
+			pq.Array(&i.Tags),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSnippetAncestors = `-- name: GetSnippetAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT snippets.id, snippets.forked_from_id, 0 AS depth
+    FROM snippets
+    WHERE snippets.id = $1
+    UNION ALL
+    SELECT snippets.id, snippets.forked_from_id, ancestors.depth + 1
+    FROM snippets
+    INNER JOIN ancestors ON snippets.id = ancestors.forked_from_id
+    WHERE ancestors.depth < 100
+)
+SELECT snippets.id, snippets.user_id, users.username, snippets.snippet_title, snippets.visibility
+FROM ancestors
+INNER JOIN snippets ON snippets.id = ancestors.id
+INNER JOIN users ON users.id = snippets.user_id
+WHERE ancestors.depth > 0
+ORDER BY ancestors.depth
+`
+
+type GetSnippetAncestorsRow struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	Username     string
+	SnippetTitle string
+	Visibility   string
+}
+
+func (q *Queries) GetSnippetAncestors(ctx context.Context, id uuid.UUID) ([]GetSnippetAncestorsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSnippetAncestors, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSnippetAncestorsRow
+	for rows.Next() {
+		var i GetSnippetAncestorsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Username,
+			&i.SnippetTitle,
+			&i.Visibility,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getSnippetById = `-- name: GetSnippetById :one
-SELECT snippets.id, snippets.created_at, snippets.updated_at, snippets.user_id, snippet_text, users.username, snippet_description, snippet_title, languages.name AS language
+SELECT snippets.id, snippets.created_at, snippets.updated_at, snippets.user_id, snippet_text, users.username, snippet_description, snippet_title, snippets.visibility, snippets.forked_from_id, languages.name AS language,
+ (SELECT COUNT(*) FROM snippets AS forks WHERE forks.forked_from_id = snippets.id AND forks.visibility = 'public') AS fork_count,
+ COALESCE((SELECT array_agg(tags.name ORDER BY tags.name) FROM tags INNER JOIN snippet_tags ON snippet_tags.tag_id = tags.id WHERE snippet_tags.snippet_id = snippets.id), '{}')::text[] AS tags,
+ snippets.star_count,
+ snippets.view_count
 FROM snippets
 INNER JOIN languages ON snippets.language_id = languages.id
 INNER JOIN users ON snippets.user_id = users.id
@@ -95,7 +369,13 @@ type GetSnippetByIdRow struct {
 	Username           string
 	SnippetDescription string
 	SnippetTitle       string
+	Visibility         string
+	ForkedFromID       uuid.NullUUID
 	Language           string
+	ForkCount          int64
+	Tags               []string
+	StarCount          int32
+	ViewCount          int32
 }
 
 func (q *Queries) GetSnippetById(ctx context.Context, id uuid.UUID) (GetSnippetByIdRow, error) {
@@ -110,7 +390,13 @@ func (q *Queries) GetSnippetById(ctx context.Context, id uuid.UUID) (GetSnippetB
 		&i.Username,
 		&i.SnippetDescription,
 		&i.SnippetTitle,
+		&i.Visibility,
+		&i.ForkedFromID,
 		&i.Language,
+		&i.ForkCount,
+		pq.Array(&i.Tags),
+		&i.StarCount,
+		&i.ViewCount,
 	)
 	return i, err
 }
@@ -121,6 +407,7 @@ FROM snippets
 INNER JOIN languages ON snippets.language_id = languages.id
 INNER JOIN users ON snippets.user_id = users.id
 WHERE search_vector @@ to_tsquery('simple', $1)
+AND snippets.visibility = 'public'
 `
 
 type GetSnippetBySearchRow struct {
@@ -168,30 +455,122 @@ func (q *Queries) GetSnippetBySearch(ctx context.Context, toTsquery string) ([]G
 	return items, nil
 }
 
-const getSnippetsByCreatedAt = `-- name: GetSnippetsByCreatedAt :many
-SELECT COUNT(*) OVER () AS total_count,
- snippets.id, snippets.created_at, snippets.updated_at, snippets.user_id, snippet_text, users.username, snippet_description, snippet_title, languages.name AS language
+const getSnippetFacets = `-- name: GetSnippetFacets :many
+WITH filtered AS (
+    SELECT filtered.id, filtered.created_at, languages.name AS language, users.username
+    FROM filter_snippets(
+        $1,
+        $2::text[],
+        $3::text[],
+        $4::timestamp,
+        $5::timestamp,
+        $6::timestamp,
+        $7::boolean,
+        $8::int,
+        $9::tsquery,
+        $10::text,
+        $11::text,
+        $12::text,
+        $13::text[],
+        $14::boolean
+    ) AS filtered
+    INNER JOIN languages ON filtered.language_id = languages.id
+    INNER JOIN users ON filtered.user_id = users.id
+)
+SELECT 'language'::text AS facet, filtered.language::text AS value, COUNT(*) AS count FROM filtered GROUP BY filtered.language
+UNION ALL
+SELECT 'author'::text, filtered.username::text, COUNT(*) FROM filtered GROUP BY filtered.username
+UNION ALL
+SELECT 'tag'::text, tags.name::text, COUNT(*) FROM filtered
+INNER JOIN snippet_tags ON snippet_tags.snippet_id = filtered.id
+INNER JOIN tags ON tags.id = snippet_tags.tag_id
+GROUP BY tags.name
+UNION ALL
+SELECT 'year'::text, EXTRACT(YEAR FROM filtered.created_at)::int::text, COUNT(*) FROM filtered GROUP BY EXTRACT(YEAR FROM filtered.created_at)
+`
+
+type GetSnippetFacetsParams struct {
+	OwnerID       uuid.NullUUID
+	Languages     []string
+	Usernames     []string
+	CreatedAfter  sql.NullTime
+	CreatedBefore sql.NullTime
+	UpdatedSince  sql.NullTime
+	HasForks      sql.NullBool
+	MinStars      sql.NullInt32
+	Search        sql.NullString
+	Fuzzy         sql.NullString
+	Substring     sql.NullString
+	RegexLiteral  sql.NullString
+	Tags          []string
+	MatchAllTags  bool
+}
+
+type GetSnippetFacetsRow struct {
+	Facet string
+	Value string
+	Count int64
+}
+
+func (q *Queries) GetSnippetFacets(ctx context.Context, arg GetSnippetFacetsParams) ([]GetSnippetFacetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSnippetFacets,
+		arg.OwnerID,
+		pq.Array(arg.Languages),
+		pq.Array(arg.Usernames),
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.UpdatedSince,
+		arg.HasForks,
+		arg.MinStars,
+		arg.Search,
+		arg.Fuzzy,
+		arg.Substring,
+		arg.RegexLiteral,
+		pq.Array(arg.Tags),
+		arg.MatchAllTags,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSnippetFacetsRow
+	for rows.Next() {
+		var i GetSnippetFacetsRow
+		if err := rows.Scan(&i.Facet, &i.Value, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSnippetForks = `-- name: GetSnippetForks :many
+SELECT snippets.id, snippets.created_at, snippets.updated_at, snippets.user_id, snippet_text, users.username, snippet_description, snippet_title, snippets.visibility, snippets.forked_from_id, languages.name AS language,
+ (SELECT COUNT(*) FROM snippets AS forks WHERE forks.forked_from_id = snippets.id AND forks.visibility = 'public') AS fork_count,
+ COALESCE((SELECT array_agg(tags.name ORDER BY tags.name) FROM tags INNER JOIN snippet_tags ON snippet_tags.tag_id = tags.id WHERE snippet_tags.snippet_id = snippets.id), '{}')::text[] AS tags,
+ snippets.star_count,
+ snippets.view_count,
+ EXISTS(SELECT 1 FROM snippet_stars WHERE snippet_stars.snippet_id = snippets.id AND snippet_stars.user_id = $1) AS starred_by_me
 FROM snippets
 INNER JOIN languages ON snippets.language_id = languages.id
 INNER JOIN users ON snippets.user_id = users.id
-WHERE
-(languages.name = $3 OR $3 IS NULL)
-AND (users.username = $4 OR $4 IS NULL)
-AND (search_vector @@ to_tsquery('simple', $5) OR $5 IS NULL)
+WHERE snippets.forked_from_id = $2
+AND (snippets.visibility = 'public' OR snippets.user_id = $1)
 ORDER BY snippets.created_at DESC
-LIMIT $1 OFFSET $2
 `
 
-type GetSnippetsByCreatedAtParams struct {
-	Limit    int32
-	Offset   int32
-	Language sql.NullString
-	Username sql.NullString
-	Search   sql.NullString
+type GetSnippetForksParams struct {
+	ViewerID uuid.NullUUID
+	ID       uuid.UUID
 }
 
-type GetSnippetsByCreatedAtRow struct {
-	TotalCount         int64
+type GetSnippetForksRow struct {
 	ID                 uuid.UUID
 	CreatedAt          time.Time
 	UpdatedAt          time.Time
@@ -200,26 +579,26 @@ type GetSnippetsByCreatedAtRow struct {
 	Username           string
 	SnippetDescription string
 	SnippetTitle       string
+	Visibility         string
+	ForkedFromID       uuid.NullUUID
 	Language           string
+	ForkCount          int64
+	Tags               []string
+	StarCount          int32
+	ViewCount          int32
+	StarredByMe        bool
 }
 
-func (q *Queries) GetSnippetsByCreatedAt(ctx context.Context, arg GetSnippetsByCreatedAtParams) ([]GetSnippetsByCreatedAtRow, error) {
-	rows, err := q.db.QueryContext(ctx, getSnippetsByCreatedAt,
-		arg.Limit,
-		arg.Offset,
-		arg.Language,
-		arg.Username,
-		arg.Search,
-	)
+func (q *Queries) GetSnippetForks(ctx context.Context, arg GetSnippetForksParams) ([]GetSnippetForksRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSnippetForks, arg.ViewerID, arg.ID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetSnippetsByCreatedAtRow
+	var items []GetSnippetForksRow
 	for rows.Next() {
-		var i GetSnippetsByCreatedAtRow
+		var i GetSnippetForksRow
 		if err := rows.Scan(
-			&i.TotalCount,
 			&i.ID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
@@ -228,7 +607,14 @@ func (q *Queries) GetSnippetsByCreatedAt(ctx context.Context, arg GetSnippetsByC
 			&i.Username,
 			&i.SnippetDescription,
 			&i.SnippetTitle,
+			&i.Visibility,
+			&i.ForkedFromID,
 			&i.Language,
+			&i.ForkCount,
+			pq.Array(&i.Tags),
+			&i.StarCount,
+			&i.ViewCount,
+			&i.StarredByMe,
 		); err != nil {
 			return nil, err
 		}
@@ -242,3 +628,151 @@ func (q *Queries) GetSnippetsByCreatedAt(ctx context.Context, arg GetSnippetsByC
 	}
 	return items, nil
 }
+
+const getSnippetsWithoutCodeVector = `-- name: GetSnippetsWithoutCodeVector :many
+SELECT snippets.id FROM snippets WHERE snippets.code_vector IS NULL AND snippets.id > $2 ORDER BY snippets.id LIMIT $1
+`
+
+type GetSnippetsWithoutCodeVectorParams struct {
+	Limit   int32
+	AfterID uuid.UUID
+}
+
+func (q *Queries) GetSnippetsWithoutCodeVector(ctx context.Context, arg GetSnippetsWithoutCodeVectorParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getSnippetsWithoutCodeVector, arg.Limit, arg.AfterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const refreshSnippetLexemes = `-- name: RefreshSnippetLexemes :exec
+REFRESH MATERIALIZED VIEW CONCURRENTLY snippet_lexemes
+`
+
+func (q *Queries) RefreshSnippetLexemes(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, refreshSnippetLexemes)
+	return err
+}
+
+const setSnippetCodeVector = `-- name: SetSnippetCodeVector :exec
+UPDATE snippets SET code_vector = $1::text::tsvector WHERE snippets.id = $2
+`
+
+type SetSnippetCodeVectorParams struct {
+	CodeVector string
+	ID         uuid.UUID
+}
+
+func (q *Queries) SetSnippetCodeVector(ctx context.Context, arg SetSnippetCodeVectorParams) error {
+	_, err := q.db.ExecContext(ctx, setSnippetCodeVector, arg.CodeVector, arg.ID)
+	return err
+}
+
+const setSnippetSearchVector = `-- name: SetSnippetSearchVector :exec
+UPDATE snippets
+SET search_vector = snippet_search_vector(snippets.id, snippets.snippet_title, snippets.snippet_description, snippets.snippet_text)
+WHERE snippets.id = $1
+`
+
+func (q *Queries) SetSnippetSearchVector(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, setSnippetSearchVector, id)
+	return err
+}
+
+const updateSnippet = `-- name: UpdateSnippet :one
+WITH updated_snippet AS (
+UPDATE snippets
+SET language_id = COALESCE($1, language_id),
+    snippet_title = COALESCE($2, snippet_title),
+    snippet_description = COALESCE($3, snippet_description),
+    snippet_text = COALESCE($4, snippet_text),
+    visibility = COALESCE($5, visibility),
+    updated_at = NOW()
+WHERE snippets.id = $6
+AND snippets.updated_at = $7
+RETURNING id, created_at, updated_at, language_id, user_id, snippet_title, snippet_description, snippet_text, search_vector, visibility, forked_from_id, star_count, code_vector, fork_count, view_count
+)
+SELECT updated_snippet.id, updated_snippet.created_at, updated_snippet.updated_at, updated_snippet.language_id, updated_snippet.user_id, updated_snippet.snippet_title, updated_snippet.snippet_description, updated_snippet.snippet_text, updated_snippet.search_vector, updated_snippet.visibility, updated_snippet.forked_from_id, updated_snippet.star_count, updated_snippet.code_vector, updated_snippet.fork_count, updated_snippet.view_count, users.username, languages.name AS language
+FROM updated_snippet
+INNER JOIN users ON users.id = updated_snippet.user_id
+INNER JOIN languages ON languages.id = updated_snippet.language_id
+`
+
+type UpdateSnippetParams struct {
+	LanguageID         uuid.NullUUID
+	SnippetTitle       sql.NullString
+	SnippetDescription sql.NullString
+	SnippetText        sql.NullString
+	Visibility         sql.NullString
+	ID                 uuid.UUID
+	UpdatedAt          time.Time
+}
+
+type UpdateSnippetRow struct {
+	ID                 uuid.UUID
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	LanguageID         uuid.UUID
+	UserID             uuid.UUID
+	SnippetTitle       string
+	SnippetDescription string
+	SnippetText        string
+	SearchVector       interface{}
+	Visibility         string
+	ForkedFromID       uuid.NullUUID
+	StarCount          int32
+	CodeVector         interface{}
+	ForkCount          int32
+	ViewCount          int32
+	Username           string
+	Language           string
+}
+
+func (q *Queries) UpdateSnippet(ctx context.Context, arg UpdateSnippetParams) (UpdateSnippetRow, error) {
+	row := q.db.QueryRowContext(ctx, updateSnippet,
+		arg.LanguageID,
+		arg.SnippetTitle,
+		arg.SnippetDescription,
+		arg.SnippetText,
+		arg.Visibility,
+		arg.ID,
+		arg.UpdatedAt,
+	)
+	var i UpdateSnippetRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LanguageID,
+		&i.UserID,
+		&i.SnippetTitle,
+		&i.SnippetDescription,
+		&i.SnippetText,
+		&i.SearchVector,
+		&i.Visibility,
+		&i.ForkedFromID,
+		&i.StarCount,
+		&i.CodeVector,
+		&i.ForkCount,
+		&i.ViewCount,
+		&i.Username,
+		&i.Language,
+	)
+	return i, err
+}
@@ -14,7 +14,7 @@ import (
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, created_at, updated_at, username, hashed_password)
 VALUES(gen_random_uuid(), NOW(), NOW(), $1, $2)
-RETURNING id, created_at, updated_at, username, hashed_password
+RETURNING id, created_at, updated_at, username, hashed_password, is_admin
 `
 
 type CreateUserParams struct {
@@ -31,12 +31,13 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.Username,
 		&i.HashedPassword,
+		&i.IsAdmin,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, created_at, updated_at, username, hashed_password
+SELECT id, created_at, updated_at, username, hashed_password, is_admin
 FROM users
 WHERE id = $1
 `
@@ -50,12 +51,13 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.UpdatedAt,
 		&i.Username,
 		&i.HashedPassword,
+		&i.IsAdmin,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, created_at, updated_at, username, hashed_password FROM users
+SELECT id, created_at, updated_at, username, hashed_password, is_admin FROM users
 WHERE username =$1
 `
 
@@ -68,6 +70,7 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.UpdatedAt,
 		&i.Username,
 		&i.HashedPassword,
+		&i.IsAdmin,
 	)
 	return i, err
 }
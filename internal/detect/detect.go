@@ -0,0 +1,270 @@
+// Package detect guesses the language code is written in.
+package detect
+
+import (
+	"cmp"
+	"math"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/TKyleB/snippetz/internal/search"
+)
+
+const (
+	shebangConfidence   = 0.99
+	extensionConfidence = 0.95
+	// Token likelihoods are scaled to at most this many tokens' worth of
+	// evidence, since naive Bayes grows overconfident on long texts
+	maxEvidenceTokens = 40
+	maxTokens         = 2000
+	maxTextBytes      = 64 << 10
+	maxAlternatives   = 3
+
+	minAlternativeConfidence = 0.01
+)
+
+// Sample is code known to be written in Language, used for training.
+type Sample struct {
+	Language string
+	Text     string
+}
+
+// Language is a language the detector can guess, by its canonical name, with
+// the other names it goes by and its file extensions.
+type Language struct {
+	Name       string
+	Aliases    []string
+	Extensions []string
+}
+
+// Guess is a language and how confident the detector is in it, from 0 to 1.
+type Guess struct {
+	Language   string  `json:"language"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Detector guesses languages from file names, shebangs, keywords and a naive
+// Bayes classifier over code tokens. It is safe for concurrent use and can
+// be retrained while in use.
+type Detector struct {
+	mu    sync.RWMutex
+	model *model
+}
+
+type model struct {
+	// names maps every lowercase name and alias to the canonical name
+	names      map[string]string
+	extensions map[string]string
+	languages  []string
+	counts     map[string]map[string]int
+	totals     map[string]int
+	vocabulary map[string]bool
+}
+
+func New() *Detector {
+	return &Detector{model: newModel(nil, nil)}
+}
+
+// Train replaces the detector's languages and its classifier with one
+// trained on samples. Samples in languages not listed are ignored.
+func (d *Detector) Train(languages []Language, samples []Sample) {
+	m := newModel(languages, samples)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.model = m
+}
+
+func newModel(languages []Language, samples []Sample) *model {
+	m := &model{
+		names:      map[string]string{},
+		extensions: map[string]string{},
+		counts:     map[string]map[string]int{},
+		totals:     map[string]int{},
+		vocabulary: map[string]bool{},
+	}
+	for _, language := range languages {
+		m.languages = append(m.languages, language.Name)
+		m.names[strings.ToLower(language.Name)] = language.Name
+		for _, alias := range language.Aliases {
+			m.names[strings.ToLower(alias)] = language.Name
+		}
+		for _, extension := range language.Extensions {
+			m.extensions[strings.ToLower(extension)] = language.Name
+		}
+		m.counts[language.Name] = map[string]int{}
+	}
+	for _, sample := range samples {
+		counts, ok := m.counts[sample.Language]
+		if !ok {
+			continue
+		}
+		for _, token := range tokens(sample.Text) {
+			counts[token]++
+			m.totals[sample.Language]++
+			m.vocabulary[token] = true
+		}
+	}
+	return m
+}
+
+// Detect guesses the language of text, best guess first, followed by up to
+// three likely alternatives. fileName may be empty; when its extension belongs to a
+// language, that language is the only guess. Detect returns nothing when
+// there is no evidence for any language.
+func (d *Detector) Detect(fileName, text string) []Guess {
+	d.mu.RLock()
+	m := d.model
+	d.mu.RUnlock()
+	if len(text) > maxTextBytes {
+		text = text[:maxTextBytes]
+	}
+
+	if extension := strings.ToLower(path.Ext(fileName)); extension != "" {
+		if language, ok := m.extensions[extension]; ok {
+			return []Guess{{Language: language, Confidence: extensionConfidence}}
+		}
+	}
+	if language, ok := m.shebang(text); ok {
+		return []Guess{{Language: language, Confidence: shebangConfidence}}
+	}
+
+	scores := map[string]float64{}
+	evidence := false
+	if classified := m.classify(text); classified != nil {
+		scores = classified
+		evidence = true
+	}
+	for _, rule := range rules {
+		language, ok := m.names[rule.language]
+		if !ok || !rule.pattern.MatchString(text) {
+			continue
+		}
+		scores[language] += rule.weight
+		evidence = true
+	}
+	if !evidence {
+		return nil
+	}
+	return rank(m.languages, scores)
+}
+
+// shebang resolves the interpreter named on a #! first line, so
+// #!/usr/bin/env python3 finds the language with a python3 or python alias.
+func (m *model) shebang(text string) (string, bool) {
+	line, _, _ := strings.Cut(text, "\n")
+	if !strings.HasPrefix(line, "#!") {
+		return "", false
+	}
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return "", false
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = field
+				break
+			}
+		}
+	}
+	for interpreter != "" {
+		if language, ok := m.names[strings.ToLower(interpreter)]; ok {
+			return language, true
+		}
+		// python3.12 falls back to python3, then python
+		trimmed := strings.TrimRight(interpreter, "0123456789.")
+		if trimmed == interpreter {
+			break
+		}
+		interpreter = trimmed
+	}
+	return "", false
+}
+
+// classify scores every language by the log likelihood of the tokens of text
+// that appear in the training samples, or returns nil if none do.
+func (m *model) classify(text string) map[string]float64 {
+	var known []string
+	for _, token := range tokens(text) {
+		if m.vocabulary[token] {
+			known = append(known, token)
+		}
+		if len(known) == maxTokens {
+			break
+		}
+	}
+	if len(known) == 0 {
+		return nil
+	}
+	vocabulary := float64(len(m.vocabulary))
+	scale := float64(min(len(known), maxEvidenceTokens)) / float64(len(known))
+	scores := map[string]float64{}
+	worst := 0.0
+	for _, language := range m.languages {
+		counts, total := m.counts[language], float64(m.totals[language])
+		if total == 0 {
+			continue
+		}
+		score := 0.0
+		for _, token := range known {
+			// Add-one smoothing keeps unseen tokens from ruling a language out
+			score += math.Log((float64(counts[token]) + 1) / (total + vocabulary))
+		}
+		scores[language] = score * scale
+		worst = min(worst, scores[language])
+	}
+	// Smoothing alone would favour languages with no samples at all, so they
+	// get no support from the classifier instead
+	for _, language := range m.languages {
+		if m.totals[language] == 0 {
+			scores[language] = worst
+		}
+	}
+	return scores
+}
+
+// rank turns log scores into probabilities and returns the most likely
+// languages.
+func rank(languages []string, scores map[string]float64) []Guess {
+	best := math.Inf(-1)
+	for _, language := range languages {
+		best = max(best, scores[language])
+	}
+	sum := 0.0
+	guesses := make([]Guess, 0, len(languages))
+	for _, language := range languages {
+		p := math.Exp(scores[language] - best)
+		sum += p
+		guesses = append(guesses, Guess{Language: language, Confidence: p})
+	}
+	for i := range guesses {
+		guesses[i].Confidence = math.Round(guesses[i].Confidence/sum*1000) / 1000
+	}
+	slices.SortStableFunc(guesses, func(a, b Guess) int {
+		return cmp.Compare(b.Confidence, a.Confidence)
+	})
+	guesses = guesses[:min(len(guesses), 1+maxAlternatives)]
+	// Alternatives that round away to nothing are not worth offering
+	for i := 1; i < len(guesses); i++ {
+		if guesses[i].Confidence < minAlternativeConfidence {
+			return guesses[:i]
+		}
+	}
+	return guesses
+}
+
+// tokens returns the whole identifiers and operators of code, which tell
+// languages apart better than the parts identifiers are split into.
+func tokens(code string) []string {
+	var tokens []string
+	for _, lexeme := range search.Tokenize(code) {
+		if !lexeme.Part {
+			tokens = append(tokens, lexeme.Text)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,111 @@
+package detect
+
+import (
+	"testing"
+)
+
+var testLanguages = []Language{
+	{Name: "go", Extensions: []string{".go"}},
+	{Name: "python", Aliases: []string{"py"}, Extensions: []string{".py"}},
+	{Name: "javascript", Aliases: []string{"js", "node"}, Extensions: []string{".js", ".mjs"}},
+	{Name: "sql", Extensions: []string{".sql"}},
+}
+
+var testSamples = []Sample{
+	{Language: "sql", Text: "SELECT name FROM users WHERE id = 1;\nINSERT INTO users VALUES (1);"},
+	{Language: "sql", Text: "SELECT count(*) FROM orders GROUP BY user_id;"},
+	{Language: "python", Text: "for item in items:\n    print(item)"},
+	// Samples in languages that are not registered are ignored
+	{Language: "cobol", Text: "MOVE A TO B"},
+}
+
+func TestDetect(t *testing.T) {
+	d := New()
+	d.Train(testLanguages, testSamples)
+
+	tests := []struct {
+		name       string
+		fileName   string
+		text       string
+		language   string
+		confidence float64
+	}{
+		{name: "empty", text: ""},
+		{name: "no evidence", text: "hello there"},
+		{name: "unregistered sample language", text: "MOVE A TO B"},
+		{name: "unregistered shebang", text: "#!/bin/bash\necho"},
+		{name: "extension", fileName: "main.GO", text: "SELECT name FROM users", language: "go", confidence: extensionConfidence},
+		{name: "unknown extension falls through", fileName: "notes.txt", text: "SELECT name FROM users", language: "sql"},
+		{name: "shebang", text: "#!/usr/bin/node\n", language: "javascript", confidence: shebangConfidence},
+		{name: "versioned shebang through env", text: "#!/usr/bin/env python3.12\nprint(1)", language: "python", confidence: shebangConfidence},
+		{name: "rules", text: "package main\n\nfunc main() {\n}\n", language: "go"},
+		{name: "rules for another language", text: "const x = () => 1;\nconsole.log(x)", language: "javascript"},
+		{name: "classifier", text: "SELECT id FROM orders", language: "sql"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guesses := d.Detect(tt.fileName, tt.text)
+			if tt.language == "" {
+				if len(guesses) != 0 {
+					t.Fatalf("Detect(%q, %q) = %v, want no guesses", tt.fileName, tt.text, guesses)
+				}
+				return
+			}
+			if len(guesses) == 0 || guesses[0].Language != tt.language {
+				t.Fatalf("Detect(%q, %q) = %v, want %s first", tt.fileName, tt.text, guesses, tt.language)
+			}
+			if tt.confidence != 0 && guesses[0].Confidence != tt.confidence {
+				t.Errorf("Detect(%q, %q) confidence = %v, want %v", tt.fileName, tt.text, guesses[0].Confidence, tt.confidence)
+			}
+			if len(guesses) > 1+maxAlternatives {
+				t.Errorf("Detect(%q, %q) returned %d guesses, want at most %d", tt.fileName, tt.text, len(guesses), 1+maxAlternatives)
+			}
+			sum := 0.0
+			for i, guess := range guesses {
+				sum += guess.Confidence
+				if i > 0 && guess.Confidence > guesses[i-1].Confidence {
+					t.Errorf("Detect(%q, %q) = %v is not ordered by confidence", tt.fileName, tt.text, guesses)
+				}
+			}
+			if sum > 1.001 {
+				t.Errorf("Detect(%q, %q) confidences add up to %v", tt.fileName, tt.text, sum)
+			}
+		})
+	}
+}
+
+func TestTrainReplaces(t *testing.T) {
+	tests := []struct {
+		name      string
+		languages []Language
+		samples   []Sample
+		fileName  string
+		text      string
+		want      string
+	}{
+		{name: "untrained", fileName: "main.go", text: "package main", want: ""},
+		{name: "trained", languages: testLanguages, samples: testSamples, fileName: "main.go", text: "x", want: "go"},
+		{name: "language removed", languages: testLanguages[1:], samples: testSamples, fileName: "main.go", text: "x", want: ""},
+		{name: "language renamed", languages: []Language{{Name: "golang", Extensions: []string{".go"}}}, fileName: "main.go", text: "x", want: "golang"},
+		{name: "trained classifier", languages: testLanguages, samples: testSamples, text: "users orders", want: "sql"},
+		{name: "samples dropped", languages: testLanguages, samples: nil, text: "users orders", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := New()
+			d.Train(testLanguages, testSamples)
+			if tt.languages == nil {
+				d = New()
+			} else {
+				d.Train(tt.languages, tt.samples)
+			}
+			got := ""
+			if guesses := d.Detect(tt.fileName, tt.text); len(guesses) > 0 {
+				got = guesses[0].Language
+			}
+			if got != tt.want {
+				t.Errorf("Detect(%q, %q) = %q, want %q", tt.fileName, tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package detect
+
+import "regexp"
+
+// rule adds weight to a language's log score when its pattern matches. The
+// language is looked up by name or alias, so rules for languages that are
+// not registered are skipped.
+type rule struct {
+	language string
+	pattern  *regexp.Regexp
+	weight   float64
+}
+
+func newRule(language, pattern string, weight float64) rule {
+	return rule{language: language, pattern: regexp.MustCompile(`(?m)` + pattern), weight: weight}
+}
+
+// rules are constructs that are rare outside one language. Weights are in
+// nats, so 3 makes a language about twenty times as likely.
+var rules = []rule{
+	newRule("python", `^\s*def \w+\(.*\)\s*(->.*)?:\s*$`, 3),
+	newRule("python", `^\s*from [\w.]+ import `, 3),
+	newRule("python", `^\s*(elif|except)\b.*:\s*$`, 3),
+	newRule("python", `if __name__ == ['"]__main__['"]:`, 4),
+	newRule("python", `\bself\.\w+`, 1),
+
+	newRule("javascript", `\bconsole\.log\(`, 3),
+	newRule("javascript", `\b(const|let)\s+\w+\s*=`, 1.5),
+	newRule("javascript", `=>`, 1),
+	newRule("javascript", `\bfunction\s*\w*\s*\(`, 2),
+	newRule("javascript", `\brequire\(['"]|module\.exports`, 3),
+	newRule("javascript", `^\s*import .* from ['"]`, 2),
+	newRule("javascript", `===|!==`, 2),
+
+	newRule("typescript", `\binterface\s+\w+\s*\{|:\s*(string|number|boolean)\b`, 2),
+
+	newRule("go", `^package \w+\s*$`, 4),
+	newRule("go", `^func\s+(\(\w+ \*?\w+\)\s*)?\w+\(`, 3),
+	newRule("go", `:=`, 1.5),
+	newRule("go", `\bfmt\.\w+\(|\berr != nil\b`, 3),
+
+	newRule("sql", `(?i)^\s*select\b[\s\S]*\bfrom\b`, 3),
+	newRule("sql", `(?i)\b(insert into|create table|alter table|delete from|create index)\b`, 4),
+	newRule("sql", `(?i)^\s*update \w+ set\b`, 4),
+
+	newRule("java", `\bpublic\s+(static\s+)?(final\s+)?(class|void|interface)\b`, 3),
+	newRule("java", `System\.out\.print`, 4),
+	newRule("java", `^\s*import java\.`, 4),
+	newRule("java", `@Override\b`, 2),
+
+	newRule("rust", `\bfn\s+\w+\s*(<.*>)?\(`, 3),
+	newRule("rust", `\blet\s+mut\b|println!\(|use std::`, 4),
+
+	newRule("ruby", `^\s*end\s*$`, 1),
+	newRule("ruby", `\bputs\b|^\s*require ['"]|\bdo \|\w+(, \w+)*\|`, 2),
+
+	newRule("php", `<\?php`, 5),
+
+	newRule("c", `#include\s*<\w+\.h>`, 3),
+	newRule("c", `\bprintf\(|\bmalloc\(`, 1.5),
+	newRule("cpp", `#include\s*<\w+>|\bstd::`, 4),
+	newRule("csharp", `\busing System\b|Console\.Write`, 4),
+
+	newRule("bash", `^\s*(fi|esac|done)\s*$`, 3),
+	newRule("bash", `^\s*(echo|export)\s`, 1.5),
+
+	newRule("html", `(?i)<!doctype html>|<(html|head|body|div|span)\b`, 4),
+	newRule("css", `^\s*[.#]?[\w-]+(\s*[,>]\s*[.#]?[\w-]+)*\s*\{\s*$`, 1),
+	newRule("css", `^\s*[\w-]+:\s*[^;]+;\s*$`, 1),
+}
@@ -0,0 +1,274 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+type OpKind int
+
+const (
+	Equal OpKind = iota
+	Insert
+	Delete
+)
+
+// Edit is a single line of an edit script. OldLine and NewLine are the
+// zero-based positions of the line in the old and new text. For inserts
+// OldLine is the position in the old text the line is inserted before, and
+// for deletes NewLine is the position in the new text the line was removed from.
+type Edit struct {
+	Kind    OpKind
+	OldLine int
+	NewLine int
+	Text    string
+}
+
+// SplitLines splits text into lines, ignoring a single trailing newline.
+func SplitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Lines returns the shortest edit script turning a into b using the
+// linear-space variant of Myers' algorithm, which splits the problem at the
+// middle snake of the edit graph instead of keeping every round of it.
+func Lines(a, b []string) []Edit {
+	d := &differ{a: a, b: b}
+	d.compare(0, len(a), 0, len(b))
+	return d.edits
+}
+
+type differ struct {
+	a, b  []string
+	edits []Edit
+}
+
+// compare appends the edits turning a[aLo:aHi] into b[bLo:bHi].
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.equal(aLo, bLo)
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.a[aHi-suffix-1] == d.b[bHi-suffix-1] {
+		suffix++
+	}
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	if aLo == aHi || bLo == bHi {
+		d.replace(aLo, aHi, bLo, bHi)
+	} else if x, y, ok := d.middleSnake(aLo, aHi, bLo, bHi); ok {
+		d.compare(aLo, x, bLo, y)
+		d.compare(x, aHi, y, bHi)
+	} else {
+		d.replace(aLo, aHi, bLo, bHi)
+	}
+
+	for i := 0; i < suffix; i++ {
+		d.equal(aHi+i, bHi+i)
+	}
+}
+
+func (d *differ) equal(x, y int) {
+	d.edits = append(d.edits, Edit{Kind: Equal, OldLine: x, NewLine: y, Text: d.a[x]})
+}
+
+// replace deletes all of a[aLo:aHi] and inserts all of b[bLo:bHi].
+func (d *differ) replace(aLo, aHi, bLo, bHi int) {
+	for x := aLo; x < aHi; x++ {
+		d.edits = append(d.edits, Edit{Kind: Delete, OldLine: x, NewLine: bLo, Text: d.a[x]})
+	}
+	for y := bLo; y < bHi; y++ {
+		d.edits = append(d.edits, Edit{Kind: Insert, OldLine: aHi, NewLine: y, Text: d.b[y]})
+	}
+}
+
+// middleSnake walks the edit graph of a[aLo:aHi] and b[bLo:bHi] forwards
+// from its start and backwards from its end at the same time, and returns a
+// point where the two paths meet. A shortest edit script passes through it,
+// so the halves on either side can be compared on their own. Both ranges
+// must be non-empty and differ in their first and last lines.
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (int, int, bool) {
+	n, m := aHi-aLo, bHi-bLo
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	// forward[k] is the furthest x reached on diagonal x-y = k from the start,
+	// and backward[k] the furthest distance walked back from the end
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+	delta := n - m
+	// When delta is odd the paths meet while walking forwards, otherwise
+	// while walking backwards
+	odd := delta%2 != 0
+
+	// Diagonals that ran off the edit graph are skipped from then on
+	kStart, kEnd, rStart, rEnd := 0, 0, 0, 0
+	for step := 0; step < maxD; step++ {
+		for k := -step + kStart; k <= step-kEnd; k += 2 {
+			var x int
+			if k == -step || (k != step && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			if x > n {
+				kEnd += 2
+			} else if y > m {
+				kStart += 2
+			} else if odd {
+				r := offset + delta - k
+				if r >= 0 && r < len(backward) && backward[r] != -1 && x >= n-backward[r] {
+					return d.split(aLo, aHi, bLo, bHi, x, y)
+				}
+			}
+		}
+		for k := -step + rStart; k <= step-rEnd; k += 2 {
+			var x int
+			if k == -step || (k != step && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aHi-x-1] == d.b[bHi-y-1] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			if x > n {
+				rEnd += 2
+			} else if y > m {
+				rStart += 2
+			} else if !odd {
+				f := offset + delta - k
+				if f >= 0 && f < len(forward) && forward[f] != -1 {
+					fx := forward[f]
+					fy := fx - (f - offset)
+					if fx >= n-x {
+						return d.split(aLo, aHi, bLo, bHi, fx, fy)
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// split turns a meeting point relative to the start of the ranges into
+// absolute line positions, refusing points that would not make the
+// problem smaller.
+func (d *differ) split(aLo, aHi, bLo, bHi, x, y int) (int, int, bool) {
+	x, y = aLo+x, bLo+y
+	if (x == aLo && y == bLo) || (x == aHi && y == bHi) {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+// MapLines returns, for every line of a, the zero-based position the line
+// ends up at in b, or -1 when the line was deleted.
+func MapLines(a, b []string) []int {
+	mapping := make([]int, len(a))
+	for i := range mapping {
+		mapping[i] = -1
+	}
+	for _, edit := range Lines(a, b) {
+		if edit.Kind == Equal {
+			mapping[edit.OldLine] = edit.NewLine
+		}
+	}
+	return mapping
+}
+
+// Unified renders the difference between a and b in unified diff format with
+// the given number of context lines. An empty string is returned when the texts are equal.
+func Unified(fromName, toName, a, b string, context int) string {
+	edits := Lines(SplitLines(a), SplitLines(b))
+
+	var sb strings.Builder
+	i := 0
+	for i < len(edits) {
+		for i < len(edits) && edits[i].Kind == Equal {
+			i++
+		}
+		if i == len(edits) {
+			break
+		}
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+		}
+
+		start := max(i-context, 0)
+		end := i
+		for {
+			for end < len(edits) && edits[end].Kind != Equal {
+				end++
+			}
+			run := end
+			for run < len(edits) && edits[run].Kind == Equal {
+				run++
+			}
+			// Merge hunks whose context would overlap
+			if run < len(edits) && run-end <= 2*context {
+				end = run
+				continue
+			}
+			end = min(end+context, len(edits))
+			break
+		}
+
+		writeHunk(&sb, edits[start:end])
+		i = end
+	}
+	return sb.String()
+}
+
+func writeHunk(sb *strings.Builder, hunk []Edit) {
+	oldCount, newCount := 0, 0
+	for _, edit := range hunk {
+		if edit.Kind != Insert {
+			oldCount++
+		}
+		if edit.Kind != Delete {
+			newCount++
+		}
+	}
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(hunk[0].OldLine, oldCount), hunkRange(hunk[0].NewLine, newCount))
+	for _, edit := range hunk {
+		switch edit.Kind {
+		case Equal:
+			sb.WriteString(" ")
+		case Insert:
+			sb.WriteString("+")
+		case Delete:
+			sb.WriteString("-")
+		}
+		sb.WriteString(edit.Text)
+		sb.WriteString("\n")
+	}
+}
+
+func hunkRange(line, count int) string {
+	// An empty range refers to the line before it, as in GNU diff
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", line+1)
+	}
+	return fmt.Sprintf("%d,%d", line+1, count)
+}
@@ -0,0 +1,214 @@
+package diff
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "empty", text: "", want: []string{}},
+		{name: "one line", text: "a", want: []string{"a"}},
+		{name: "trailing newline", text: "a\nb\n", want: []string{"a", "b"}},
+		{name: "blank lines", text: "a\n\n\n", want: []string{"a", "", ""}},
+		{name: "only a newline", text: "\n", want: []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitLines(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("SplitLines(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		// edits is the script as one character per line: = + or -
+		edits string
+	}{
+		{name: "both empty", a: "", b: "", edits: ""},
+		{name: "identical", a: "a\nb\nc", b: "a\nb\nc", edits: "==="},
+		{name: "from empty", a: "", b: "a\nb", edits: "++"},
+		{name: "to empty", a: "a\nb", b: "", edits: "--"},
+		{name: "insert in the middle", a: "a\nc", b: "a\nb\nc", edits: "=+="},
+		{name: "delete in the middle", a: "a\nb\nc", b: "a\nc", edits: "=-="},
+		{name: "replace deletes first", a: "a\nb\nc", b: "a\nx\nc", edits: "=-+="},
+		{name: "nothing in common", a: "a\nb", b: "c\nd", edits: "--++"},
+		{name: "repeated lines", a: "a\na\nb", b: "a\nb\na\nb", edits: "=+=="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := SplitLines(tt.a), SplitLines(tt.b)
+			edits := Lines(a, b)
+			checkEdits(t, a, b, edits)
+			var got strings.Builder
+			for _, edit := range edits {
+				got.WriteByte("=+-"[edit.Kind])
+			}
+			if got.String() != tt.edits {
+				t.Errorf("Lines(%q, %q) = %s, want %s", tt.a, tt.b, got.String(), tt.edits)
+			}
+		})
+	}
+}
+
+// TestLinesShortest checks the edit scripts of random texts against the
+// length of their longest common subsequence.
+func TestLinesShortest(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, random.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + random.Intn(4)))
+		}
+		return lines
+	}
+	for range 2000 {
+		a, b := randomLines(), randomLines()
+		edits := Lines(a, b)
+		checkEdits(t, a, b, edits)
+		equal := 0
+		for _, edit := range edits {
+			if edit.Kind == Equal {
+				equal++
+			}
+		}
+		if want := lcsLength(a, b); equal != want {
+			t.Fatalf("Lines(%q, %q) keeps %d lines, want %d", a, b, equal, want)
+		}
+	}
+}
+
+// checkEdits fails unless edits turns a into b with consistent positions.
+func checkEdits(t *testing.T, a, b []string, edits []Edit) {
+	t.Helper()
+	oldLine, newLine := 0, 0
+	for _, edit := range edits {
+		if edit.OldLine != oldLine || edit.NewLine != newLine {
+			t.Fatalf("edit %+v is at %d, %d, want %d, %d", edit, edit.OldLine, edit.NewLine, oldLine, newLine)
+		}
+		switch edit.Kind {
+		case Equal:
+			if a[oldLine] != edit.Text || b[newLine] != edit.Text {
+				t.Fatalf("equal edit %+v does not match both texts", edit)
+			}
+			oldLine++
+			newLine++
+		case Delete:
+			if a[oldLine] != edit.Text {
+				t.Fatalf("delete %+v does not match the old text", edit)
+			}
+			oldLine++
+		case Insert:
+			if b[newLine] != edit.Text {
+				t.Fatalf("insert %+v does not match the new text", edit)
+			}
+			newLine++
+		}
+	}
+	if oldLine != len(a) || newLine != len(b) {
+		t.Fatalf("edits end at %d, %d, want %d, %d", oldLine, newLine, len(a), len(b))
+	}
+}
+
+func lcsLength(a, b []string) int {
+	lengths := make([]int, len(b)+1)
+	for i := range a {
+		previous := 0
+		for j := range b {
+			current := lengths[j+1]
+			if a[i] == b[j] {
+				lengths[j+1] = previous + 1
+			} else {
+				lengths[j+1] = max(lengths[j+1], lengths[j])
+			}
+			previous = current
+		}
+	}
+	return lengths[len(b)]
+}
+
+func TestMapLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []int
+	}{
+		{name: "empty", a: "", b: "a", want: []int{}},
+		{name: "identical", a: "a\nb", b: "a\nb", want: []int{0, 1}},
+		{name: "inserted above", a: "a\nb", b: "x\na\nb", want: []int{1, 2}},
+		{name: "deleted", a: "a\nb\nc", b: "a\nc", want: []int{0, -1, 1}},
+		{name: "changed", a: "a\nb", b: "a\nx", want: []int{0, -1}},
+		{name: "all removed", a: "a\nb", b: "", want: []int{-1, -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MapLines(SplitLines(tt.a), SplitLines(tt.b)); !slices.Equal(got, tt.want) {
+				t.Errorf("MapLines(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		want    string
+	}{
+		{name: "both empty", a: "", b: "", context: 3, want: ""},
+		{name: "identical", a: "a\nb\n", b: "a\nb\n", context: 3, want: ""},
+		{
+			name:    "from empty",
+			a:       "",
+			b:       "a\nb\n",
+			context: 3,
+			want:    "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:    "one change with context",
+			a:       "1\n2\n3\n4\n5\n",
+			b:       "1\n2\nx\n4\n5\n",
+			context: 1,
+			want:    "--- old\n+++ new\n@@ -2,3 +2,3 @@\n 2\n-3\n+x\n 4\n",
+		},
+		{
+			name:    "separate hunks",
+			a:       "1\n2\n3\n4\n5\n6\n7\n",
+			b:       "x\n2\n3\n4\n5\n6\ny\n",
+			context: 1,
+			want:    "--- old\n+++ new\n@@ -1,2 +1,2 @@\n-1\n+x\n 2\n@@ -6,2 +6,2 @@\n 6\n-7\n+y\n",
+		},
+		{
+			name:    "hunks merged when context overlaps",
+			a:       "1\n2\n3\n4\n",
+			b:       "x\n2\n3\ny\n",
+			context: 1,
+			want:    "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n-4\n+y\n",
+		},
+		{
+			name:    "deleted line",
+			a:       "1\n2\n3\n",
+			b:       "1\n3\n",
+			context: 0,
+			want:    "--- old\n+++ new\n@@ -2 +1,0 @@\n-2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified("old", "new", tt.a, tt.b, tt.context); got != tt.want {
+				t.Errorf("Unified(%q, %q, %d) =\n%s\nwant\n%s", tt.a, tt.b, tt.context, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+// Package listing builds and runs the snippet listing query. It is written
+// by hand rather than generated because each sort needs its own ORDER BY and
+// keyset predicate.
+package listing
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// The ORDER BY and keyset predicate of each sort are written as plain column
+// lists so the planner can walk the index for that sort. Only the fragments
+// below are ever put into the query; everything a caller sends is a
+// parameter.
+
+const listSnippetsColumns = `SELECT snippets.id, snippets.created_at, snippets.updated_at, snippets.user_id, snippet_text, users.username, snippet_description, snippet_title, snippets.visibility, snippets.forked_from_id, languages.name AS language,
+ snippets.fork_count::bigint AS fork_count,
+ COALESCE((SELECT array_agg(tags.name ORDER BY tags.name) FROM tags INNER JOIN snippet_tags ON snippet_tags.tag_id = tags.id WHERE snippet_tags.snippet_id = snippets.id), '{}')::text[] AS tags,
+ snippets.star_count,
+ snippets.view_count,
+ EXISTS(SELECT 1 FROM snippet_stars WHERE snippet_stars.snippet_id = snippets.id AND snippet_stars.user_id = $3) AS starred_by_me,
+ CASE WHEN $4::text IS NULL THEN '' ELSE ts_headline('simple', escape_html(snippets.snippet_title), $4::tsquery, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') END::text AS title_highlight,
+ CASE WHEN $4::text IS NULL THEN '' ELSE ts_headline('simple', escape_html(snippets.snippet_description), $4::tsquery, 'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15') END::text AS description_highlight,
+ CASE WHEN $4::text IS NULL THEN '' ELSE ts_headline('simple', escape_html(COALESCE(
+    (SELECT string_agg(snippet_files.file_text, E'\n' ORDER BY snippet_files.position) FROM snippet_files WHERE snippet_files.snippet_id = snippets.id),
+    snippets.snippet_text
+ )), $4::tsquery, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=3, MaxWords=20, MinWords=5, FragmentDelimiter=" ... "') END::text AS code_highlight,
+ COALESCE(scores.similarity, 0)::real AS similarity,
+`
+
+const listSnippetsFrom = `FROM filter_snippets(
+    $5,
+    $6::text[],
+    $7::text[],
+    $8::timestamp,
+    $9::timestamp,
+    $10::timestamp,
+    $11::boolean,
+    $12::int,
+    $4::tsquery,
+    $13::text,
+    $14::text,
+    $15::text,
+    $16::text[],
+    $17::boolean
+) AS snippets
+INNER JOIN languages ON snippets.language_id = languages.id
+INNER JOIN users ON snippets.user_id = users.id
+CROSS JOIN LATERAL (
+    SELECT ts_rank_cd(snippets.search_vector || COALESCE(snippets.code_vector, ''::tsvector), $4::tsquery) AS relevance,
+    GREATEST(
+        similarity(snippets.snippet_title, COALESCE($13::text, $14::text)),
+        word_similarity(COALESCE($13::text, $14::text), snippets.snippet_title),
+        (SELECT MAX(word_similarity(COALESCE($13::text, $14::text), snippet_files.file_text)) FROM snippet_files
+         WHERE COALESCE($13::text, $14::text) IS NOT NULL AND snippet_files.snippet_id = snippets.id)
+    ) AS similarity
+) AS scores
+`
+
+// listingSort is how one sort orders snippets ahead of created_at and id.
+type listingSort struct {
+	// column is the sort key, or empty for the created order
+	column string
+	// cursorParam is the cursor's value for column, cast to its type
+	cursorParam string
+	// numeric sort keys are returned as sort_value and kept in the cursor
+	numeric bool
+	// cursorValue picks the cursor's value for column out of the params
+	cursorValue func(ListSnippetsParams) interface{}
+}
+
+func cursorValue(arg ListSnippetsParams) interface{}     { return arg.CursorValue }
+func cursorUpdatedAt(arg ListSnippetsParams) interface{} { return arg.CursorUpdatedAt }
+func cursorTitle(arg ListSnippetsParams) interface{}     { return arg.CursorTitle }
+
+var listingSorts = map[string]listingSort{
+	"created":    {},
+	"updated":    {column: "snippets.updated_at", cursorParam: "$20::timestamp", cursorValue: cursorUpdatedAt},
+	"title":      {column: "snippets.snippet_title", cursorParam: "$20::text", cursorValue: cursorTitle},
+	"stars":      {column: "snippets.star_count", cursorParam: "$20::float8::int", numeric: true, cursorValue: cursorValue},
+	"views":      {column: "snippets.view_count", cursorParam: "$20::float8::int", numeric: true, cursorValue: cursorValue},
+	"forks":      {column: "snippets.fork_count", cursorParam: "$20::float8::int", numeric: true, cursorValue: cursorValue},
+	"relevance":  {column: "scores.relevance", cursorParam: "$20::float8::real", numeric: true, cursorValue: cursorValue},
+	"similarity": {column: "scores.similarity", cursorParam: "$20::float8::real", numeric: true, cursorValue: cursorValue},
+}
+
+// listingQuery identifies one of the prebuilt listing queries.
+type listingQuery struct {
+	sort       string
+	ascending  bool
+	fromCursor bool
+}
+
+// listingQueries holds the query for every sort, direction and whether the
+// listing starts from a cursor, so no SQL is put together per request.
+var listingQueries = buildListingQueries()
+
+func buildListingQueries() map[listingQuery]string {
+	queries := make(map[listingQuery]string)
+	for name, sort := range listingSorts {
+		for _, ascending := range []bool{false, true} {
+			for _, fromCursor := range []bool{false, true} {
+				queries[listingQuery{name, ascending, fromCursor}] = listSnippetsQuery(sort, ascending, fromCursor)
+			}
+		}
+	}
+	return queries
+}
+
+// listSnippetsQuery builds the listing query for a sort and direction, with
+// a keyset predicate when paging from a cursor.
+func listSnippetsQuery(sort listingSort, ascending, fromCursor bool) string {
+	sortValue := "0"
+	if sort.numeric {
+		sortValue = sort.column
+	}
+	columns, values := "snippets.created_at, snippets.id", "$18::timestamp, $19::uuid"
+	if sort.column != "" {
+		columns, values = sort.column+", "+columns, sort.cursorParam+", "+values
+	}
+	comparison, direction := "<", "DESC"
+	if ascending {
+		comparison, direction = ">", "ASC"
+	}
+
+	query := listSnippetsColumns + " (" + sortValue + ")::float8 AS sort_value\n" + listSnippetsFrom
+	if fromCursor {
+		query += "WHERE (" + columns + ") " + comparison + " (" + values + ")\n"
+	}
+	query += "ORDER BY "
+	if sort.column != "" {
+		query += sort.column + " " + direction + ", "
+	}
+	query += "snippets.created_at " + direction + ", snippets.id " + direction + "\n"
+	return query + "LIMIT $1 OFFSET $2"
+}
+
+// ListSnippetsParams filters, sorts and pages a snippet listing. Sort is one
+// of created, updated, title, stars, views, forks, relevance or similarity.
+// When CursorID is set the listing continues after the cursor's snippet,
+// using the cursor field that matches the sort.
+type ListSnippetsParams struct {
+	Limit           int32
+	Offset          int32
+	ViewerID        uuid.NullUUID
+	Search          sql.NullString
+	Sort            string
+	OwnerID         uuid.NullUUID
+	Languages       []string
+	Usernames       []string
+	CreatedAfter    sql.NullTime
+	CreatedBefore   sql.NullTime
+	UpdatedSince    sql.NullTime
+	HasForks        sql.NullBool
+	MinStars        sql.NullInt32
+	Fuzzy           sql.NullString
+	Substring       sql.NullString
+	RegexLiteral    sql.NullString
+	Tags            []string
+	MatchAllTags    bool
+	CursorID        uuid.NullUUID
+	Ascending       bool
+	CursorValue     sql.NullFloat64
+	CursorCreatedAt sql.NullTime
+	CursorUpdatedAt sql.NullTime
+	CursorTitle     sql.NullString
+}
+
+// ListSnippetsRow is one snippet of a listing. SortValue is the value of a
+// numeric sort key, kept so a cursor can be made from the row.
+type ListSnippetsRow struct {
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	UserID               uuid.UUID
+	SnippetText          string
+	Username             string
+	SnippetDescription   string
+	SnippetTitle         string
+	Visibility           string
+	ForkedFromID         uuid.NullUUID
+	Language             string
+	ForkCount            int64
+	Tags                 []string
+	StarCount            int32
+	ViewCount            int32
+	StarredByMe          bool
+	TitleHighlight       string
+	DescriptionHighlight string
+	CodeHighlight        string
+	Similarity           float32
+	SortValue            float64
+}
+
+// ListSnippets returns the snippets of a listing in the order of its sort.
+func ListSnippets(ctx context.Context, db database.DBTX, arg ListSnippetsParams) ([]ListSnippetsRow, error) {
+	sort, ok := listingSorts[arg.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort: %s", arg.Sort)
+	}
+	args := []interface{}{
+		arg.Limit,
+		arg.Offset,
+		arg.ViewerID,
+		arg.Search,
+		arg.OwnerID,
+		pq.Array(arg.Languages),
+		pq.Array(arg.Usernames),
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.UpdatedSince,
+		arg.HasForks,
+		arg.MinStars,
+		arg.Fuzzy,
+		arg.Substring,
+		arg.RegexLiteral,
+		pq.Array(arg.Tags),
+		arg.MatchAllTags,
+	}
+	fromCursor := arg.CursorID.Valid
+	if fromCursor {
+		args = append(args, arg.CursorCreatedAt, arg.CursorID)
+		if sort.cursorValue != nil {
+			args = append(args, sort.cursorValue(arg))
+		}
+	}
+	query := listingQueries[listingQuery{arg.Sort, arg.Ascending, fromCursor}]
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSnippetsRow
+	for rows.Next() {
+		var i ListSnippetsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.SnippetText,
+			&i.Username,
+			&i.SnippetDescription,
+			&i.SnippetTitle,
+			&i.Visibility,
+			&i.ForkedFromID,
+			&i.Language,
+			&i.ForkCount,
+			pq.Array(&i.Tags),
+			&i.StarCount,
+			&i.ViewCount,
+			&i.StarredByMe,
+			&i.TitleHighlight,
+			&i.DescriptionHighlight,
+			&i.CodeHighlight,
+			&i.Similarity,
+			&i.SortValue,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
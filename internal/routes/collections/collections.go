@@ -0,0 +1,361 @@
+package collections
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TKyleB/snippetz/internal/auth"
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/TKyleB/snippetz/internal/routes/snippets"
+	"github.com/TKyleB/snippetz/internal/utilites"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const maxNameLength = 100
+
+var errCollectionNotFound = errors.New("collection not found")
+
+type CollectionsHandler struct {
+	DB          *sql.DB
+	DbQueries   *database.Queries
+	AuthService *auth.AuthService
+}
+
+type Collection struct {
+	ID           uuid.UUID `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	UserID       uuid.UUID `json:"author_id"`
+	UserName     string    `json:"username"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	Visibility   string    `json:"visibility"`
+	SnippetCount int64     `json:"snippet_count"`
+}
+
+type CollectionsResponse struct {
+	Count       int32        `json:"count"`
+	Next        *string      `json:"next"`
+	Previous    *string      `json:"previous"`
+	Collections []Collection `json:"collections"`
+}
+
+func (c *CollectionsHandler) CreateCollection(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Visibility  string `json:"visibility"`
+	}
+
+	user, err := c.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	params := parameters{}
+	err = utilites.DecodeJsonBody(w, r, &params)
+	if err != nil {
+		return
+	}
+	params.Name = strings.TrimSpace(params.Name)
+	if err := validateName(params.Name); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if params.Visibility == "" {
+		params.Visibility = snippets.VisibilityPublic
+	}
+	if !snippets.IsValidVisibility(params.Visibility) {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "visibility must be one of public, unlisted or private")
+		return
+	}
+
+	collection, err := c.DbQueries.CreateCollection(r.Context(), database.CreateCollectionParams{UserID: user.ID, Name: params.Name, Description: params.Description, Visibility: params.Visibility})
+	if err != nil {
+		if isUniqueViolation(err) {
+			utilites.ResponseWithError(w, r, http.StatusConflict, "you already have a collection with that name")
+			return
+		}
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "error creating collection")
+		return
+	}
+
+	utilites.ResponseWithJson(w, r, http.StatusCreated, Collection{
+		ID:          collection.ID,
+		CreatedAt:   collection.CreatedAt,
+		UpdatedAt:   collection.UpdatedAt,
+		UserID:      collection.UserID,
+		UserName:    collection.Username,
+		Name:        collection.Name,
+		Description: collection.Description,
+		Visibility:  collection.Visibility,
+	})
+}
+
+// GetCollections lists public collections, or all of the caller's own
+// collections when mine=true.
+func (c *CollectionsHandler) GetCollections(w http.ResponseWriter, r *http.Request) {
+	var count int32
+	limit := int32(20)
+	offset := int32(0)
+
+	var ownerID uuid.NullUUID
+	var username sql.NullString
+	mineString := r.URL.Query().Get("mine")
+	usernameString := r.URL.Query().Get("username")
+	limitString := r.URL.Query().Get("limit")
+	offsetString := r.URL.Query().Get("offset")
+
+	if limitString != "" {
+		parseLimit, err := snippets.ParseIntParam("limit", limitString, 1, snippets.MaxLimit)
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		limit = parseLimit
+	}
+	if offsetString != "" {
+		parseOffset, err := snippets.ParseIntParam("offset", offsetString, 0, math.MaxInt32)
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		offset = parseOffset
+	}
+	mine := false
+	if mineString != "" {
+		var err error
+		mine, err = snippets.ParseBoolParam("mine", mineString)
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if mine {
+		user, err := c.AuthService.GetAuthenticatedUser(r)
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+			return
+		}
+		ownerID = uuid.NullUUID{UUID: user.ID, Valid: true}
+	}
+	if usernameString != "" {
+		username.Scan(usernameString)
+	}
+
+	dbCollections, err := c.DbQueries.GetCollections(r.Context(), database.GetCollectionsParams{Limit: limit, Offset: offset, OwnerID: ownerID, Username: username})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "error fetching collections")
+		return
+	}
+
+	collections := []Collection{}
+	for i, collection := range dbCollections {
+		if i == 0 {
+			count = int32(collection.TotalCount)
+		}
+		collections = append(collections, Collection{
+			ID:           collection.ID,
+			CreatedAt:    collection.CreatedAt,
+			UpdatedAt:    collection.UpdatedAt,
+			UserID:       collection.UserID,
+			UserName:     collection.Username,
+			Name:         collection.Name,
+			Description:  collection.Description,
+			Visibility:   collection.Visibility,
+			SnippetCount: collection.SnippetCount,
+		})
+	}
+
+	next, previous := pageURLs(r, "/api/collections", limit, offset, count)
+	utilites.ResponseWithJson(w, r, http.StatusOK, CollectionsResponse{Count: count, Next: next, Previous: previous, Collections: collections})
+}
+
+func (c *CollectionsHandler) GetCollectionById(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	collection, err := c.getVisibleCollection(r, id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utilites.ResponseWithJson(w, r, http.StatusOK, Collection{
+		ID:           collection.ID,
+		CreatedAt:    collection.CreatedAt,
+		UpdatedAt:    collection.UpdatedAt,
+		UserID:       collection.UserID,
+		UserName:     collection.Username,
+		Name:         collection.Name,
+		Description:  collection.Description,
+		Visibility:   collection.Visibility,
+		SnippetCount: collection.SnippetCount,
+	})
+}
+
+func (c *CollectionsHandler) UpdateCollection(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		Visibility  *string `json:"visibility"`
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	user, err := c.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if _, err := c.getOwnedCollection(w, r, id, user); err != nil {
+		return
+	}
+
+	params := parameters{}
+	err = utilites.DecodeJsonBody(w, r, &params)
+	if err != nil {
+		return
+	}
+
+	updateParams := database.UpdateCollectionParams{ID: id}
+	if params.Name != nil {
+		name := strings.TrimSpace(*params.Name)
+		if err := validateName(name); err != nil {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		updateParams.Name.Scan(name)
+	}
+	if params.Description != nil {
+		updateParams.Description.Scan(*params.Description)
+	}
+	if params.Visibility != nil {
+		if !snippets.IsValidVisibility(*params.Visibility) {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, "visibility must be one of public, unlisted or private")
+			return
+		}
+		updateParams.Visibility.Scan(*params.Visibility)
+	}
+
+	collection, err := c.DbQueries.UpdateCollection(r.Context(), updateParams)
+	if err != nil {
+		if isUniqueViolation(err) {
+			utilites.ResponseWithError(w, r, http.StatusConflict, "you already have a collection with that name")
+			return
+		}
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "error updating collection")
+		return
+	}
+
+	utilites.ResponseWithJson(w, r, http.StatusOK, Collection{
+		ID:           collection.ID,
+		CreatedAt:    collection.CreatedAt,
+		UpdatedAt:    collection.UpdatedAt,
+		UserID:       collection.UserID,
+		UserName:     collection.Username,
+		Name:         collection.Name,
+		Description:  collection.Description,
+		Visibility:   collection.Visibility,
+		SnippetCount: collection.SnippetCount,
+	})
+}
+
+func (c *CollectionsHandler) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	user, err := c.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if _, err := c.getOwnedCollection(w, r, id, user); err != nil {
+		return
+	}
+
+	c.DbQueries.DeleteCollection(r.Context(), id)
+	utilites.ResponseWithJson(w, r, http.StatusNoContent, "")
+}
+
+// getVisibleCollection fetches a collection, hiding private collections from
+// everyone but their owner by reporting them as not found.
+func (c *CollectionsHandler) getVisibleCollection(r *http.Request, id uuid.UUID) (database.GetCollectionByIdRow, error) {
+	collection, err := c.DbQueries.GetCollectionById(r.Context(), id)
+	if err != nil {
+		return database.GetCollectionByIdRow{}, errCollectionNotFound
+	}
+	viewer, _ := c.AuthService.GetAuthenticatedUser(r)
+	if !snippets.CanView(collection.Visibility, collection.UserID, viewer) {
+		return database.GetCollectionByIdRow{}, errCollectionNotFound
+	}
+	return collection, nil
+}
+
+// getOwnedCollection fetches a collection the user is about to modify and
+// writes the error response when it is missing or belongs to someone else.
+func (c *CollectionsHandler) getOwnedCollection(w http.ResponseWriter, r *http.Request, id uuid.UUID, user *auth.User) (database.GetCollectionByIdRow, error) {
+	collection, err := c.getVisibleCollection(r, id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, err.Error())
+		return database.GetCollectionByIdRow{}, err
+	}
+	if collection.UserID != user.ID {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, "")
+		return database.GetCollectionByIdRow{}, errors.New("not the collection owner")
+	}
+	return collection, nil
+}
+
+func validateName(name string) error {
+	if len(name) == 0 {
+		return errors.New("name is empty")
+	}
+	if len(name) > maxNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxNameLength)
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func pageURLs(r *http.Request, path string, limit, offset, count int32) (*string, *string) {
+	var next *string
+	var previous *string
+
+	if !(offset+limit >= count) {
+		next = pageURL(r, path, limit, offset+limit)
+	}
+	if offset > 0 {
+		previous = pageURL(r, path, limit, max(offset-limit, 0))
+	}
+	return next, previous
+}
+
+// pageURL links to the page at offset, keeping every other query parameter
+// such as the listing's filters.
+func pageURL(r *http.Request, path string, limit, offset int32) *string {
+	query := r.URL.Query()
+	query.Set("limit", strconv.Itoa(int(limit)))
+	query.Set("offset", strconv.Itoa(int(offset)))
+	url := fmt.Sprintf("%s://%s%s?%s", r.URL.Scheme, r.Host, path, query.Encode())
+	return &url
+}
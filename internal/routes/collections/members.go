@@ -0,0 +1,288 @@
+package collections
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/TKyleB/snippetz/internal/routes/snippets"
+	"github.com/TKyleB/snippetz/internal/utilites"
+	"github.com/google/uuid"
+)
+
+// GetCollectionSnippets lists a collection's snippets in their saved order
+// using the same envelope as the snippet listing. Snippets that have since
+// been made private are only shown to their owner.
+func (c *CollectionsHandler) GetCollectionSnippets(w http.ResponseWriter, r *http.Request) {
+	var count int32
+	limit := int32(5)
+	offset := int32(0)
+	limitString := r.URL.Query().Get("limit")
+	offsetString := r.URL.Query().Get("offset")
+
+	if limitString != "" {
+		parseLimit, err := snippets.ParseIntParam("limit", limitString, 1, snippets.MaxLimit)
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		limit = parseLimit
+	}
+	if offsetString != "" {
+		parseOffset, err := snippets.ParseIntParam("offset", offsetString, 0, math.MaxInt32)
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		offset = parseOffset
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	collection, err := c.getVisibleCollection(r, id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	var viewerID uuid.NullUUID
+	if viewer, err := c.AuthService.GetAuthenticatedUser(r); err == nil {
+		viewerID = uuid.NullUUID{UUID: viewer.ID, Valid: true}
+	}
+
+	dbSnippets, err := c.DbQueries.GetCollectionSnippets(r.Context(), database.GetCollectionSnippetsParams{Limit: limit, Offset: offset, ViewerID: viewerID, CollectionID: collection.ID})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "error fetching snippets")
+		return
+	}
+
+	languageCounts := make(map[string]int)
+	var results []snippets.Snippet
+	for i, snippet := range dbSnippets {
+		if i == 0 {
+			count = int32(snippet.TotalCount)
+		}
+
+		languageCounts[snippet.Language] += 1
+
+		var forkedFromID *uuid.UUID
+		if snippet.ForkedFromID.Valid {
+			forkedFromID = &snippet.ForkedFromID.UUID
+		}
+		results = append(results, snippets.Snippet{
+			ID:           snippet.ID,
+			CreatedAt:    snippet.CreatedAt,
+			UpdatedAt:    snippet.UpdatedAt,
+			Language:     snippet.Language,
+			UserID:       snippet.UserID,
+			SnippetText:  snippet.SnippetText,
+			UserName:     snippet.Username,
+			SnippetDesc:  snippet.SnippetDescription,
+			SnippetTitle: snippet.SnippetTitle,
+			Visibility:   snippet.Visibility,
+			ForkedFromID: forkedFromID,
+			ForkCount:    snippet.ForkCount,
+			StarCount:    snippet.StarCount,
+			ViewCount:    snippet.ViewCount,
+			StarredByMe:  snippet.StarredByMe,
+			Tags:         snippet.Tags,
+		})
+	}
+
+	next, previous := pageURLs(r, fmt.Sprintf("/api/collections/%s/snippets", collection.ID), limit, offset, count)
+	response := snippets.SnippetsResponse{
+		Count:    &count,
+		Next:     next,
+		Previous: previous,
+		Results:  snippets.Results{Snippets: results, Languages: languageCounts},
+	}
+	utilites.ResponseWithJson(w, r, http.StatusOK, &response)
+}
+
+// AddCollectionSnippet appends a snippet to the end of a collection. Any
+// snippet the owner can see may be added, including other users' snippets.
+func (c *CollectionsHandler) AddCollectionSnippet(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		SnippetID uuid.UUID `json:"snippet_id"`
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	user, err := c.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if _, err := c.getOwnedCollection(w, r, id, user); err != nil {
+		return
+	}
+
+	params := parameters{}
+	err = utilites.DecodeJsonBody(w, r, &params)
+	if err != nil {
+		return
+	}
+	snippet, err := c.DbQueries.GetSnippetById(r.Context(), params.SnippetID)
+	if err != nil || !snippets.CanView(snippet.Visibility, snippet.UserID, user) {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, "snippet not found")
+		return
+	}
+
+	tx, err := c.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "error adding snippet to collection")
+		return
+	}
+	defer tx.Rollback()
+	qtx := c.DbQueries.WithTx(tx)
+	// The new position is one past the last, so concurrent adds to the same
+	// collection take turns
+	err = qtx.LockCollection(r.Context(), id)
+	if err == nil {
+		err = qtx.AddCollectionSnippet(r.Context(), database.AddCollectionSnippetParams{CollectionID: id, SnippetID: snippet.ID})
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "error adding snippet to collection")
+		return
+	}
+	utilites.ResponseWithJson(w, r, http.StatusNoContent, "")
+}
+
+func (c *CollectionsHandler) RemoveCollectionSnippet(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	snippetID, err := uuid.Parse(r.PathValue("snippet_id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid snippet_id")
+		return
+	}
+	user, err := c.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if _, err := c.getOwnedCollection(w, r, id, user); err != nil {
+		return
+	}
+
+	tx, err := c.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "error removing snippet from collection")
+		return
+	}
+	defer tx.Rollback()
+	qtx := c.DbQueries.WithTx(tx)
+	// Removals wait for a reorder in progress, which checked the snippets it
+	// orders are all in the collection
+	var removed int64
+	err = qtx.LockCollection(r.Context(), id)
+	if err == nil {
+		removed, err = qtx.RemoveCollectionSnippet(r.Context(), database.RemoveCollectionSnippetParams{CollectionID: id, SnippetID: snippetID})
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "error removing snippet from collection")
+		return
+	}
+	if removed == 0 {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, "snippet not found in collection")
+		return
+	}
+	utilites.ResponseWithJson(w, r, http.StatusNoContent, "")
+}
+
+// ReorderCollectionSnippets sets the order of a collection. The request must
+// list every snippet in the collection exactly once.
+func (c *CollectionsHandler) ReorderCollectionSnippets(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		SnippetIDs []uuid.UUID `json:"snippet_ids"`
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	user, err := c.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if _, err := c.getOwnedCollection(w, r, id, user); err != nil {
+		return
+	}
+
+	params := parameters{}
+	err = utilites.DecodeJsonBody(w, r, &params)
+	if err != nil {
+		return
+	}
+
+	tx, err := c.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "error reordering collection")
+		return
+	}
+	defer tx.Rollback()
+	qtx := c.DbQueries.WithTx(tx)
+	// Adds and removals wait until the new order is written, so the snippets
+	// checked below are still the collection's when it is
+	err = qtx.LockCollection(r.Context(), id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "error reordering collection")
+		return
+	}
+
+	current, err := qtx.GetCollectionSnippetIds(r.Context(), id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "error reordering collection")
+		return
+	}
+	if !sameMembers(current, params.SnippetIDs) {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "snippet_ids must list every snippet in the collection exactly once")
+		return
+	}
+
+	ids := make([]string, len(params.SnippetIDs))
+	for i, snippetID := range params.SnippetIDs {
+		ids[i] = snippetID.String()
+	}
+	err = qtx.ReorderCollectionSnippets(r.Context(), database.ReorderCollectionSnippetsParams{SnippetIds: ids, CollectionID: id})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "error reordering collection")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "error reordering collection")
+		return
+	}
+	utilites.ResponseWithJson(w, r, http.StatusNoContent, "")
+}
+
+// sameMembers reports whether ordered is a permutation of current.
+func sameMembers(current, ordered []uuid.UUID) bool {
+	if len(current) != len(ordered) {
+		return false
+	}
+	compare := func(a, b uuid.UUID) int {
+		return slices.Compare(a[:], b[:])
+	}
+	a := slices.SortedFunc(slices.Values(current), compare)
+	b := slices.SortedFunc(slices.Values(ordered), compare)
+	return slices.Equal(a, b)
+}
@@ -0,0 +1,260 @@
+package snippets
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/TKyleB/snippetz/internal/utilites"
+	"github.com/google/uuid"
+)
+
+const maxCommentLength = 10000
+
+// Comment is a Markdown comment on a snippet. Deleted comments keep their
+// place in the thread so replies stay attached, but lose their body and author.
+type Comment struct {
+	ID        uuid.UUID   `json:"id"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	ParentID  *uuid.UUID  `json:"parent_id"`
+	UserID    *uuid.UUID  `json:"author_id"`
+	UserName  string      `json:"username"`
+	Body      string      `json:"body"`
+	Edited    bool        `json:"edited"`
+	Deleted   bool        `json:"deleted"`
+	Anchor    *LineAnchor `json:"anchor,omitempty"`
+	Replies   []*Comment  `json:"replies"`
+}
+
+func newComment(row database.GetSnippetCommentRow) *Comment {
+	comment := &Comment{
+		ID:        row.ID,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+		ParentID:  nullUUIDPtr(row.ParentID),
+		Edited:    row.UpdatedAt.After(row.CreatedAt),
+		Deleted:   row.DeletedAt.Valid,
+		Anchor:    newLineAnchor(row),
+		Replies:   []*Comment{},
+	}
+	if !comment.Deleted {
+		comment.UserID = &row.UserID
+		comment.UserName = row.Username
+		comment.Body = row.Body
+	}
+	return comment
+}
+
+func validateCommentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if len(body) == 0 {
+		return "", errors.New("body is empty")
+	}
+	if len(body) > maxCommentLength {
+		return "", fmt.Errorf("body must be at most %d characters", maxCommentLength)
+	}
+	return body, nil
+}
+
+// GetSnippetComments returns a snippet's general comments as a tree of
+// threads, oldest first at every level.
+func (s *SnippetsHandler) GetSnippetComments(w http.ResponseWriter, r *http.Request) {
+	s.listComments(w, r, false)
+}
+
+// listComments writes either the general or the line-anchored comment threads
+// of a snippet. Replies share their thread's anchor, so each list holds whole threads.
+func (s *SnippetsHandler) listComments(w http.ResponseWriter, r *http.Request, anchored bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if _, err := s.getVisibleSnippet(r, id); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	rows, err := s.DbQueries.GetSnippetComments(r.Context(), database.GetSnippetCommentsParams{SnippetID: id, Anchored: anchored})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	// Replies are always newer than their parent, so the parent has been
+	// seen by the time a reply is reached
+	threads := []*Comment{}
+	byID := make(map[uuid.UUID]*Comment, len(rows))
+	for _, row := range rows {
+		comment := newComment(database.GetSnippetCommentRow(row))
+		byID[comment.ID] = comment
+		if parent, ok := byID[row.ParentID.UUID]; row.ParentID.Valid && ok {
+			parent.Replies = append(parent.Replies, comment)
+			continue
+		}
+		threads = append(threads, comment)
+	}
+	utilites.ResponseWithJson(w, r, http.StatusOK, &threads)
+}
+
+func (s *SnippetsHandler) CreateSnippetComment(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body     string     `json:"body"`
+		ParentID *uuid.UUID `json:"parent_id"`
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	user, err := s.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if _, err := s.getVisibleSnippet(r, id); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	params := parameters{}
+	err = utilites.DecodeJsonBody(w, r, &params)
+	if err != nil {
+		return
+	}
+	body, err := validateCommentBody(params.Body)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	createParams := database.CreateSnippetCommentParams{SnippetID: id, UserID: user.ID, Body: body}
+	if params.ParentID != nil {
+		parent, err := s.DbQueries.GetSnippetComment(r.Context(), database.GetSnippetCommentParams{ID: *params.ParentID, SnippetID: id})
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, "parent comment not found")
+			return
+		}
+		if parent.DeletedAt.Valid {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, "cannot reply to a deleted comment")
+			return
+		}
+		createParams.ParentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		// Replies to a line comment join its thread at the same anchor
+		createParams.Revision = parent.Revision
+		createParams.FileName = parent.FileName
+		createParams.StartLine = parent.StartLine
+		createParams.EndLine = parent.EndLine
+		createParams.CurrentStartLine = parent.CurrentStartLine
+		createParams.CurrentEndLine = parent.CurrentEndLine
+		createParams.Outdated = parent.Outdated
+	}
+
+	comment, err := s.DbQueries.CreateSnippetComment(r.Context(), createParams)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	utilites.ResponseWithJson(w, r, http.StatusCreated, newComment(database.GetSnippetCommentRow(comment)))
+}
+
+// UpdateSnippetComment edits a comment's body. Only the comment's author may
+// edit it, and deleted comments cannot be edited.
+func (s *SnippetsHandler) UpdateSnippetComment(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	id, commentID, err := parseCommentPath(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	user, err := s.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if _, err := s.getVisibleSnippet(r, id); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	comment, err := s.DbQueries.GetSnippetComment(r.Context(), database.GetSnippetCommentParams{ID: commentID, SnippetID: id})
+	if err != nil || comment.DeletedAt.Valid {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, "comment not found")
+		return
+	}
+	if comment.UserID != user.ID {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, "")
+		return
+	}
+
+	params := parameters{}
+	err = utilites.DecodeJsonBody(w, r, &params)
+	if err != nil {
+		return
+	}
+	body, err := validateCommentBody(params.Body)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := s.DbQueries.UpdateSnippetComment(r.Context(), database.UpdateSnippetCommentParams{Body: body, ID: comment.ID})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, "comment not found")
+		return
+	}
+	utilites.ResponseWithJson(w, r, http.StatusOK, newComment(database.GetSnippetCommentRow(updated)))
+}
+
+// DeleteSnippetComment soft deletes a comment. Authors can delete their own
+// comments and snippet owners can moderate any comment on their snippets.
+func (s *SnippetsHandler) DeleteSnippetComment(w http.ResponseWriter, r *http.Request) {
+	id, commentID, err := parseCommentPath(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	user, err := s.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+	snippet, err := s.getVisibleSnippet(r, id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	comment, err := s.DbQueries.GetSnippetComment(r.Context(), database.GetSnippetCommentParams{ID: commentID, SnippetID: id})
+	if err != nil || comment.DeletedAt.Valid {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, "comment not found")
+		return
+	}
+	if comment.UserID != user.ID && snippet.UserID != user.ID {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, "")
+		return
+	}
+
+	err = s.DbQueries.DeleteSnippetComment(r.Context(), database.DeleteSnippetCommentParams{ID: comment.ID, DeletedBy: uuid.NullUUID{UUID: user.ID, Valid: true}})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	utilites.ResponseWithJson(w, r, http.StatusNoContent, "")
+}
+
+func parseCommentPath(r *http.Request) (uuid.UUID, uuid.UUID, error) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.New("invalid id")
+	}
+	commentID, err := uuid.Parse(r.PathValue("comment_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.New("invalid comment_id")
+	}
+	return id, commentID, nil
+}
@@ -0,0 +1,89 @@
+package snippets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	searchquery "github.com/TKyleB/snippetz/internal/search"
+	"github.com/TKyleB/snippetz/internal/utilites"
+	"github.com/google/uuid"
+)
+
+const (
+	maxCompletionPrefix = 100
+	maxCompletionLimit  = 20
+)
+
+// GetSearchSuggestions completes a search prefix from the titles, tags,
+// authors and languages of public snippets.
+func (s *SnippetsHandler) GetSearchSuggestions(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	if prefix == "" || utf8.RuneCountInString(prefix) > maxCompletionPrefix {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, fmt.Sprintf("prefix must be between 1 and %d characters", maxCompletionPrefix))
+		return
+	}
+	limit := 10
+	if limitString := r.URL.Query().Get("limit"); limitString != "" {
+		parsed, err := ParseIntParam("limit", limitString, 1, maxCompletionLimit)
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		limit = int(parsed)
+	}
+
+	completions := s.Completions.Complete(prefix, limit)
+	if completions == nil {
+		completions = []searchquery.Completion{}
+	}
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	utilites.ResponseWithJson(w, r, http.StatusOK, completions)
+}
+
+// LoadCompletions fills the completion index from every public snippet.
+// After that, handlers that change a snippet update its entry as they go.
+func (s *SnippetsHandler) LoadCompletions(ctx context.Context) error {
+	rows, err := s.DbQueries.GetPublicSnippetTerms(ctx)
+	if err != nil {
+		return err
+	}
+	docs := make([]searchquery.CompletionDocument, 0, len(rows))
+	for _, row := range rows {
+		docs = append(docs, searchquery.CompletionDocument{
+			ID:       row.ID,
+			Title:    row.SnippetTitle,
+			Username: row.Username,
+			Language: row.Language,
+			Tags:     row.Tags,
+		})
+	}
+	s.Completions.Replace(docs)
+	return nil
+}
+
+// snippetSaved updates what the handler keeps in memory about snippets after
+// one is created or edited. Snippets that are not public are dropped from
+// the completion index.
+func (s *SnippetsHandler) snippetSaved(snippet Snippet) {
+	s.languages.invalidate()
+	if snippet.Visibility != VisibilityPublic {
+		s.Completions.Remove(snippet.ID)
+		return
+	}
+	s.Completions.Add(searchquery.CompletionDocument{
+		ID:       snippet.ID,
+		Title:    snippet.SnippetTitle,
+		Username: snippet.UserName,
+		Language: snippet.Language,
+		Tags:     snippet.Tags,
+	})
+}
+
+// snippetDeleted drops a deleted snippet from what the handler keeps in
+// memory.
+func (s *SnippetsHandler) snippetDeleted(id uuid.UUID) {
+	s.languages.invalidate()
+	s.Completions.Remove(id)
+}
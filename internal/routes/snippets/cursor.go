@@ -0,0 +1,103 @@
+package snippets
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/TKyleB/snippetz/internal/listing"
+	"github.com/google/uuid"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// cursor marks a position in a snippet listing by the sort key, creation time
+// and id of the snippet next to it. Numeric sort keys are kept in Value, while
+// updated and title orders use UpdatedAt and Title. Before cursors page
+// backwards from that snippet, the others page forwards.
+type cursor struct {
+	Sort      string    `json:"s"`
+	Ascending bool      `json:"a,omitempty"`
+	Value     float64   `json:"v,omitempty"`
+	UpdatedAt time.Time `json:"u"`
+	Title     string    `json:"x,omitempty"`
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+	Before    bool      `json:"b,omitempty"`
+}
+
+func newCursor(sort string, ascending bool, snippet listing.ListSnippetsRow, before bool) cursor {
+	c := cursor{Sort: sort, Ascending: ascending, Value: snippet.SortValue, CreatedAt: snippet.CreatedAt, ID: snippet.ID, Before: before}
+	switch sort {
+	case "updated":
+		c.UpdatedAt = snippet.UpdatedAt
+	case "title":
+		c.Title = snippet.SnippetTitle
+	}
+	return c
+}
+
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, errInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return cursor{}, errInvalidCursor
+	}
+	return c, nil
+}
+
+// apply limits params to the snippets after the cursor.
+func (c cursor) apply(params *listing.ListSnippetsParams) {
+	params.CursorID = uuid.NullUUID{UUID: c.ID, Valid: true}
+	params.CursorValue = sql.NullFloat64{Float64: c.Value, Valid: true}
+	params.CursorCreatedAt = sql.NullTime{Time: c.CreatedAt, Valid: true}
+	params.CursorUpdatedAt = sql.NullTime{Time: c.UpdatedAt, Valid: true}
+	params.CursorTitle = sql.NullString{String: c.Title, Valid: true}
+}
+
+// cursorPage fetches the page of snippets after c, or the first page when c
+// is nil, and reports whether there are more snippets past it. Pages before a
+// cursor are fetched in reverse and flipped back into order.
+func (s *SnippetsHandler) cursorPage(r *http.Request, params listing.ListSnippetsParams, c *cursor) ([]listing.ListSnippetsRow, bool, error) {
+	limit := params.Limit
+	params.Limit = limit + 1
+	if c != nil {
+		c.apply(&params)
+		params.Offset = 0
+		params.Ascending = params.Ascending != c.Before
+	}
+	rows, err := listing.ListSnippets(r.Context(), s.DB, params)
+	if err != nil {
+		return nil, false, err
+	}
+	more := int32(len(rows)) > limit
+	if more {
+		rows = rows[:limit]
+	}
+	if c != nil && c.Before {
+		slices.Reverse(rows)
+	}
+	return rows, more, nil
+}
+
+// pageURL links to the page next to c, keeping every other query parameter.
+func pageURL(r *http.Request, c cursor) *string {
+	query := r.URL.Query()
+	query.Del("offset")
+	query.Set("cursor", c.encode())
+	url := fmt.Sprintf("%s://%s%s?%s", r.URL.Scheme, r.Host, r.URL.Path, query.Encode())
+	return &url
+}
@@ -0,0 +1,91 @@
+package snippets
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/TKyleB/snippetz/internal/detect"
+	"github.com/TKyleB/snippetz/internal/utilites"
+)
+
+const (
+	// autoLanguage asks for the language of a snippet or file to be detected
+	autoLanguage = "auto"
+	// fallbackLanguage is used when nothing points to any language
+	fallbackLanguage = "text"
+
+	detectSamplesPerLanguage = 200
+	detectSampleLength       = 20000
+)
+
+// LanguageDetection is the detected language of some code, how confident
+// the guess is from 0 to 1, and the next most likely languages.
+type LanguageDetection struct {
+	Language     string         `json:"language"`
+	Confidence   float64        `json:"confidence"`
+	Alternatives []detect.Guess `json:"alternatives"`
+}
+
+// DetectLanguage guesses the language of code without saving anything.
+func (s *SnippetsHandler) DetectLanguage(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Text     string `json:"text"`
+		FileName string `json:"file_name"`
+	}
+
+	params := parameters{}
+	err := utilites.DecodeJsonBody(w, r, &params)
+	if err != nil {
+		return
+	}
+	if len(params.Text) == 0 {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "text is empty")
+		return
+	}
+	utilites.ResponseWithJson(w, r, http.StatusOK, s.detectLanguage(params.FileName, params.Text))
+}
+
+// TrainDetector retrains the language detector on the latest public files in
+// each language, leaving out files whose language was itself detected. It
+// runs on startup, periodically and when languages change. Runs are one at a
+// time, so the last one to finish trained on the latest languages.
+func (s *SnippetsHandler) TrainDetector(ctx context.Context) error {
+	s.training.Lock()
+	defer s.training.Unlock()
+	rows, err := s.DbQueries.GetLanguages(ctx)
+	if err != nil {
+		return err
+	}
+	languages := make([]detect.Language, 0, len(rows))
+	for _, row := range rows {
+		languages = append(languages, detect.Language{Name: row.Name, Aliases: row.Aliases, Extensions: row.Extensions})
+	}
+	sampleRows, err := s.DbQueries.GetLanguageSamples(ctx, database.GetLanguageSamplesParams{MaxLength: detectSampleLength, PerLanguage: detectSamplesPerLanguage})
+	if err != nil {
+		return err
+	}
+	samples := make([]detect.Sample, 0, len(sampleRows))
+	for _, row := range sampleRows {
+		samples = append(samples, detect.Sample{Language: row.Language, Text: row.FileText})
+	}
+	s.Detector.Train(languages, samples)
+	return nil
+}
+
+// detectLanguage guesses the language of a file. fileName may be empty.
+func (s *SnippetsHandler) detectLanguage(fileName, text string) LanguageDetection {
+	guesses := s.Detector.Detect(fileName, text)
+	if len(guesses) == 0 {
+		return LanguageDetection{Language: fallbackLanguage, Alternatives: []detect.Guess{}}
+	}
+	return LanguageDetection{Language: guesses[0].Language, Confidence: guesses[0].Confidence, Alternatives: guesses[1:]}
+}
+
+// isAutoLanguage reports whether a language was left out or set to auto,
+// asking for it to be detected.
+func isAutoLanguage(language string) bool {
+	language = strings.TrimSpace(language)
+	return language == "" || strings.EqualFold(language, autoLanguage)
+}
@@ -0,0 +1,29 @@
+package snippets
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// snippetETag derives a strong ETag from a snippet's updated_at timestamp.
+// Postgres stores timestamps with microsecond precision so that is the
+// resolution used here.
+func snippetETag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMicro(), 36) + `"`
+}
+
+// parseETag reverses snippetETag, returning the updated_at timestamp the tag was built from.
+func parseETag(etag string) (time.Time, error) {
+	etag = strings.TrimSpace(etag)
+	etag = strings.TrimPrefix(etag, "W/")
+	if len(etag) < 2 || !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) {
+		return time.Time{}, errors.New("malformed etag")
+	}
+	micros, err := strconv.ParseInt(etag[1:len(etag)-1], 36, 64)
+	if err != nil {
+		return time.Time{}, errors.New("malformed etag")
+	}
+	return time.UnixMicro(micros).UTC(), nil
+}
@@ -0,0 +1,59 @@
+package snippets
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/TKyleB/snippetz/internal/listing"
+)
+
+// Facets count the snippets a listing matches by language, author, tag and
+// year created, across every page rather than only the one returned.
+type Facets struct {
+	Languages map[string]int `json:"languages"`
+	Authors   map[string]int `json:"authors"`
+	Tags      map[string]int `json:"tags"`
+	Years     map[string]int `json:"years"`
+}
+
+func newFacets() *Facets {
+	return &Facets{
+		Languages: make(map[string]int),
+		Authors:   make(map[string]int),
+		Tags:      make(map[string]int),
+		Years:     make(map[string]int),
+	}
+}
+
+// count tallies a snippet the listing matched.
+func (f *Facets) count(snippet listing.ListSnippetsRow) {
+	f.Languages[snippet.Language]++
+	f.Authors[snippet.Username]++
+	for _, tag := range snippet.Tags {
+		f.Tags[tag]++
+	}
+	f.Years[strconv.Itoa(snippet.CreatedAt.Year())]++
+}
+
+// getFacets counts every snippet the filters match in one grouped query.
+func (s *SnippetsHandler) getFacets(ctx context.Context, params database.GetSnippetFacetsParams) (*Facets, error) {
+	rows, err := s.DbQueries.GetSnippetFacets(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	facets := newFacets()
+	for _, row := range rows {
+		switch row.Facet {
+		case "language":
+			facets.Languages[row.Value] = int(row.Count)
+		case "author":
+			facets.Authors[row.Value] = int(row.Count)
+		case "tag":
+			facets.Tags[row.Value] = int(row.Count)
+		case "year":
+			facets.Years[row.Value] = int(row.Count)
+		}
+	}
+	return facets, nil
+}
@@ -0,0 +1,241 @@
+package snippets
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/TKyleB/snippetz/internal/search"
+	"github.com/TKyleB/snippetz/internal/utilites"
+	"github.com/google/uuid"
+)
+
+const maxSnippetFiles = 20
+
+// SnippetFile is one named file of a multi-file snippet. The first file is
+// mirrored into the snippet's own language and snippet_text.
+type SnippetFile struct {
+	Name     string `json:"name"`
+	Language string `json:"language"`
+	Text     string `json:"text"`
+}
+
+// resolvedFile is a SnippetFile whose language has been looked up.
+// LanguageDetected is set when the language was detected rather than given.
+type resolvedFile struct {
+	SnippetFile
+	LanguageID       uuid.UUID
+	LanguageDetected bool
+}
+
+// fileExtension returns the extension files in a language are named with.
+func fileExtension(language database.Language) string {
+	if len(language.Extensions) == 0 {
+		return ".txt"
+	}
+	return language.Extensions[0]
+}
+
+// languageExtension looks up the extension for files in the named language,
+// falling back to .txt for languages that have since been removed.
+func (s *SnippetsHandler) languageExtension(ctx context.Context, name string) string {
+	language, err := s.DbQueries.GetLanguageByName(ctx, name)
+	if err != nil {
+		return ".txt"
+	}
+	return fileExtension(language)
+}
+
+// defaultFileName names the single file of a snippet created without files.
+func defaultFileName(extension string) string {
+	return "snippet" + extension
+}
+
+func validateFiles(files []SnippetFile) error {
+	if len(files) > maxSnippetFiles {
+		return fmt.Errorf("a snippet can have at most %d files", maxSnippetFiles)
+	}
+	seen := make(map[string]bool)
+	for _, file := range files {
+		if file.Name == "" || len(file.Name) > 255 {
+			return errors.New("file name must be between 1 and 255 characters")
+		}
+		if strings.ContainsAny(file.Name, `/\"`) || strings.IndexFunc(file.Name, unicode.IsControl) >= 0 || file.Name == "." || file.Name == ".." {
+			return fmt.Errorf("file name: %s is invalid", file.Name)
+		}
+		if seen[file.Name] {
+			return fmt.Errorf("file name: %s is used more than once", file.Name)
+		}
+		seen[file.Name] = true
+		if len(file.Text) == 0 {
+			return fmt.Errorf("file: %s is empty", file.Name)
+		}
+	}
+	return nil
+}
+
+func (s *SnippetsHandler) resolveFiles(ctx context.Context, files []SnippetFile) ([]resolvedFile, error) {
+	resolved := make([]resolvedFile, 0, len(files))
+	for _, file := range files {
+		language, err := s.DbQueries.GetLanguageByName(ctx, file.Language)
+		if err != nil {
+			return nil, fmt.Errorf("language: %s is not currently supported", file.Language)
+		}
+		file.Language = language.Name
+		resolved = append(resolved, resolvedFile{SnippetFile: file, LanguageID: language.ID})
+	}
+	return resolved, nil
+}
+
+// writeSnippetFiles stores files in order. Callers replacing a snippet's
+// files must delete the existing ones first.
+func writeSnippetFiles(ctx context.Context, qtx *database.Queries, snippetID uuid.UUID, files []resolvedFile) error {
+	for i, file := range files {
+		_, err := qtx.CreateSnippetFile(ctx, database.CreateSnippetFileParams{
+			SnippetID:        snippetID,
+			Position:         int32(i),
+			FileName:         file.Name,
+			LanguageID:       file.LanguageID,
+			FileText:         file.Text,
+			LanguageDetected: file.LanguageDetected,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getSnippetFiles(ctx context.Context, queries *database.Queries, snippetID uuid.UUID) ([]SnippetFile, error) {
+	dbFiles, err := queries.GetSnippetFiles(ctx, snippetID)
+	if err != nil {
+		return nil, err
+	}
+	files := []SnippetFile{}
+	for _, file := range dbFiles {
+		files = append(files, SnippetFile{Name: file.FileName, Language: file.Language, Text: file.FileText})
+	}
+	return files, nil
+}
+
+// indexSnippetCode tokenizes a snippet's file names and code into its code
+// search vector. It must run after the files are written.
+func indexSnippetCode(ctx context.Context, queries *database.Queries, snippetID uuid.UUID) error {
+	files, err := getSnippetFiles(ctx, queries, snippetID)
+	if err != nil {
+		return err
+	}
+	var lexemes []search.Lexeme
+	for _, file := range files {
+		lexemes = append(lexemes, search.Tokenize(file.Name)...)
+		lexemes = append(lexemes, search.Tokenize(file.Text)...)
+	}
+	return queries.SetSnippetCodeVector(ctx, database.SetSnippetCodeVectorParams{CodeVector: search.CodeVector(lexemes), ID: snippetID})
+}
+
+// BackfillCodeIndex tokenizes snippets stored before the code index existed.
+// It is run in the background on startup. Snippets are visited in id order,
+// so one that fails to index is logged and skipped rather than retried.
+func (s *SnippetsHandler) BackfillCodeIndex(ctx context.Context) error {
+	var after uuid.UUID
+	for {
+		ids, err := s.DbQueries.GetSnippetsWithoutCodeVector(ctx, database.GetSnippetsWithoutCodeVectorParams{Limit: 100, AfterID: after})
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		for _, id := range ids {
+			if err := indexSnippetCode(ctx, s.DbQueries, id); err != nil {
+				log.Printf("Error indexing code of snippet %s. %v", id, err)
+			}
+		}
+		after = ids[len(ids)-1]
+	}
+}
+
+// revisionFiles decodes the files snapshot stored with a revision.
+func revisionFiles(raw json.RawMessage) ([]SnippetFile, error) {
+	var stored []struct {
+		FileName string `json:"file_name"`
+		Language string `json:"language"`
+		FileText string `json:"file_text"`
+	}
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return nil, err
+	}
+	files := []SnippetFile{}
+	for _, file := range stored {
+		files = append(files, SnippetFile{Name: file.FileName, Language: file.Language, Text: file.FileText})
+	}
+	return files, nil
+}
+
+func (s *SnippetsHandler) GetSnippetFile(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if _, err := s.getVisibleSnippet(r, id); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	file, err := s.DbQueries.GetSnippetFileByName(r.Context(), database.GetSnippetFileByNameParams{SnippetID: id, FileName: r.PathValue("name")})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, "file not found")
+		return
+	}
+	w.Header().Add("Vary", "Accept")
+	if prefersPlainText(r) {
+		writeRaw(w, file.FileName, file.FileText)
+		return
+	}
+	utilites.ResponseWithJson(w, r, http.StatusOK, SnippetFile{Name: file.FileName, Language: file.Language, Text: file.FileText})
+}
+
+func (s *SnippetsHandler) GetSnippetArchive(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if _, err := s.getVisibleSnippet(r, id); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	files, err := getSnippetFiles(r.Context(), s.DbQueries, id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, id))
+	w.WriteHeader(http.StatusOK)
+	// The status is already sent, so failures can only be logged and leave
+	// the client with a truncated archive
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		entry, err := archive.Create(file.Name)
+		if err == nil {
+			_, err = entry.Write([]byte(file.Text))
+		}
+		if err != nil {
+			log.Printf("Error writing archive of snippet %s. %v", id, err)
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		log.Printf("Error writing archive of snippet %s. %v", id, err)
+	}
+}
@@ -0,0 +1,76 @@
+package snippets
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// MaxLimit is the most items one page of a listing can hold.
+const MaxLimit = 100
+
+// ParseIntParam parses an integer query parameter, which must lie between min
+// and max inclusive.
+func ParseIntParam(name, value string, min, max int64) (int32, error) {
+	n, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%s must be an integer between %d and %d", name, min, max)
+	}
+	return int32(n), nil
+}
+
+// ParseBoolParam parses a true or false query parameter.
+func ParseBoolParam(name, value string) (bool, error) {
+	switch value {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("%s must be true or false", name)
+}
+
+// parseTimeParam parses a date such as 2025-01-31, meaning midnight UTC, or an
+// RFC 3339 time such as 2025-01-31T15:04:05Z.
+func parseTimeParam(name, value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("%s must be a date like 2025-01-31 or a time like 2025-01-31T15:04:05Z", name)
+}
+
+// narrowAfter keeps the later of two lower bounds.
+func narrowAfter(bound sql.NullTime, t time.Time) sql.NullTime {
+	if !bound.Valid || t.After(bound.Time) {
+		return sql.NullTime{Time: t, Valid: true}
+	}
+	return bound
+}
+
+// narrowBefore keeps the earlier of two upper bounds.
+func narrowBefore(bound sql.NullTime, t time.Time) sql.NullTime {
+	if !bound.Valid || t.Before(bound.Time) {
+		return sql.NullTime{Time: t, Valid: true}
+	}
+	return bound
+}
+
+// narrowValues keeps the values of a filter that are also in another filter
+// on the same field. A nil filter allows any value, and an empty one none.
+func narrowValues(filter, values []string) []string {
+	if filter == nil {
+		return values
+	}
+	narrowed := []string{}
+	for _, value := range filter {
+		if slices.Contains(values, value) {
+			narrowed = append(narrowed, value)
+		}
+	}
+	return narrowed
+}
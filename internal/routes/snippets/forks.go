@@ -0,0 +1,166 @@
+package snippets
+
+import (
+	"net/http"
+
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/TKyleB/snippetz/internal/utilites"
+	"github.com/google/uuid"
+)
+
+// ForkParent is one step in the chain of snippets a fork was copied from.
+type ForkParent struct {
+	ID           uuid.UUID `json:"id"`
+	UserID       uuid.UUID `json:"author_id"`
+	UserName     string    `json:"username"`
+	SnippetTitle string    `json:"snippet_title"`
+}
+
+func nullUUIDPtr(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
+
+func (s *SnippetsHandler) ForkSnippet(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	user, err := s.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+	parent, err := s.getVisibleSnippet(r, id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	tx, err := s.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	defer tx.Rollback()
+	qtx := s.DbQueries.WithTx(tx)
+
+	fork, err := qtx.ForkSnippet(r.Context(), database.ForkSnippetParams{UserID: user.ID, ID: id})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	err = qtx.CopySnippetFiles(r.Context(), database.CopySnippetFilesParams{SnippetID: fork.ID, SourceID: id})
+	if err == nil {
+		err = qtx.CopySnippetTags(r.Context(), database.CopySnippetTagsParams{SnippetID: fork.ID, SourceID: id})
+	}
+	if err == nil {
+		err = qtx.SetSnippetSearchVector(r.Context(), fork.ID)
+	}
+	if err == nil {
+		err = indexSnippetCode(r.Context(), qtx, fork.ID)
+	}
+	if err == nil {
+		err = qtx.CreateSnippetRevision(r.Context(), fork.ID)
+	}
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	files, err := getSnippetFiles(r.Context(), qtx, fork.ID)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	saved := Snippet{
+		ID:           fork.ID,
+		CreatedAt:    fork.CreatedAt,
+		UpdatedAt:    fork.UpdatedAt,
+		Language:     fork.Language,
+		UserID:       fork.UserID,
+		SnippetText:  fork.SnippetText,
+		SnippetDesc:  fork.SnippetDescription,
+		SnippetTitle: fork.SnippetTitle,
+		Visibility:   fork.Visibility,
+		ForkedFromID: nullUUIDPtr(fork.ForkedFromID),
+		UserName:     fork.Username,
+		Files:        files,
+		Tags:         parent.Tags,
+	}
+	s.snippetSaved(saved)
+	utilites.ResponseWithJson(w, r, http.StatusCreated, saved)
+}
+
+func (s *SnippetsHandler) GetSnippetForks(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if _, err := s.getVisibleSnippet(r, id); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	var viewerID uuid.NullUUID
+	if viewer := s.viewer(r); viewer != nil {
+		viewerID = uuid.NullUUID{UUID: viewer.ID, Valid: true}
+	}
+	dbForks, err := s.DbQueries.GetSnippetForks(r.Context(), database.GetSnippetForksParams{ViewerID: viewerID, ID: id})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	forks := []Snippet{}
+	for _, fork := range dbForks {
+		forks = append(forks, Snippet{
+			ID:           fork.ID,
+			CreatedAt:    fork.CreatedAt,
+			UpdatedAt:    fork.UpdatedAt,
+			Language:     fork.Language,
+			UserID:       fork.UserID,
+			SnippetText:  fork.SnippetText,
+			UserName:     fork.Username,
+			SnippetDesc:  fork.SnippetDescription,
+			SnippetTitle: fork.SnippetTitle,
+			Visibility:   fork.Visibility,
+			ForkedFromID: nullUUIDPtr(fork.ForkedFromID),
+			ForkCount:    fork.ForkCount,
+			StarCount:    fork.StarCount,
+			ViewCount:    fork.ViewCount,
+			StarredByMe:  fork.StarredByMe,
+			Tags:         fork.Tags,
+		})
+	}
+	utilites.ResponseWithJson(w, r, http.StatusOK, &forks)
+}
+
+// getForkChain returns the snippets a snippet was forked from, nearest first.
+// Private ancestors the viewer cannot see end the chain.
+func (s *SnippetsHandler) getForkChain(r *http.Request, id uuid.UUID) ([]ForkParent, error) {
+	ancestors, err := s.DbQueries.GetSnippetAncestors(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	viewer := s.viewer(r)
+	chain := []ForkParent{}
+	for _, ancestor := range ancestors {
+		if !CanView(ancestor.Visibility, ancestor.UserID, viewer) {
+			break
+		}
+		chain = append(chain, ForkParent{
+			ID:           ancestor.ID,
+			UserID:       ancestor.UserID,
+			UserName:     ancestor.Username,
+			SnippetTitle: ancestor.SnippetTitle,
+		})
+	}
+	return chain, nil
+}
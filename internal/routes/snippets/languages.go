@@ -0,0 +1,482 @@
+package snippets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TKyleB/snippetz/internal/auth"
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/TKyleB/snippetz/internal/utilites"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	maxLanguageName    = 32
+	maxLanguageAliases = 20
+	maxCommentSyntax   = 10
+)
+
+var (
+	errLanguageNotFound = errors.New("language not found")
+	// Detection falls back to this language, so it must always exist
+	errFallbackLanguage = fmt.Errorf("%s is the fallback for language detection and can't be renamed or deleted", fallbackLanguage)
+)
+
+// CommentSyntax is how comments are written in a language. Languages without
+// block comments have an empty BlockStart and BlockEnd.
+type CommentSyntax struct {
+	Line       string `json:"line"`
+	BlockStart string `json:"block_start"`
+	BlockEnd   string `json:"block_end"`
+}
+
+type Language struct {
+	ID            uuid.UUID     `json:"id"`
+	Name          string        `json:"name"`
+	DisplayName   string        `json:"display_name"`
+	Aliases       []string      `json:"aliases"`
+	Extensions    []string      `json:"extensions"`
+	MimeType      string        `json:"mime_type"`
+	CommentSyntax CommentSyntax `json:"comment_syntax"`
+}
+
+// LanguageStats is a language with how many public snippets are written in
+// it, and when the latest of them was created.
+type LanguageStats struct {
+	Language
+	SnippetCount    int64      `json:"snippet_count"`
+	LatestSnippetAt *time.Time `json:"latest_snippet_at"`
+}
+
+// languageCache holds the GET /api/languages response between changes to
+// snippets or languages. The version stops a listing read before an
+// invalidation from being cached after it.
+type languageCache struct {
+	mu        sync.Mutex
+	version   int
+	languages []LanguageStats
+}
+
+func (c *languageCache) get() ([]LanguageStats, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.languages, c.version
+}
+
+func (c *languageCache) set(version int, languages []LanguageStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version == c.version {
+		c.languages = languages
+	}
+}
+
+func (c *languageCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version++
+	c.languages = nil
+}
+
+func newLanguage(language database.Language, aliases []string) Language {
+	if aliases == nil {
+		aliases = []string{}
+	}
+	extensions := language.Extensions
+	if extensions == nil {
+		extensions = []string{}
+	}
+	return Language{
+		ID:          language.ID,
+		Name:        language.Name,
+		DisplayName: language.DisplayName,
+		Aliases:     aliases,
+		Extensions:  extensions,
+		MimeType:    language.MimeType,
+		CommentSyntax: CommentSyntax{
+			Line:       language.LineComment,
+			BlockStart: language.BlockCommentStart,
+			BlockEnd:   language.BlockCommentEnd,
+		},
+	}
+}
+
+// GetLanguages lists every language with its usage by public snippets.
+func (s *SnippetsHandler) GetLanguages(w http.ResponseWriter, r *http.Request) {
+	languages, version := s.languages.get()
+	if languages == nil {
+		rows, err := s.DbQueries.GetLanguages(r.Context())
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+			return
+		}
+		languages = []LanguageStats{}
+		for _, row := range rows {
+			language := database.Language{
+				ID:                row.ID,
+				Name:              row.Name,
+				DisplayName:       row.DisplayName,
+				Extensions:        row.Extensions,
+				MimeType:          row.MimeType,
+				LineComment:       row.LineComment,
+				BlockCommentStart: row.BlockCommentStart,
+				BlockCommentEnd:   row.BlockCommentEnd,
+			}
+			stats := LanguageStats{Language: newLanguage(language, row.Aliases), SnippetCount: row.SnippetCount}
+			if row.LatestSnippetAt.Valid {
+				stats.LatestSnippetAt = &row.LatestSnippetAt.Time
+			}
+			languages = append(languages, stats)
+		}
+		s.languages.set(version, languages)
+	}
+	utilites.ResponseWithJson(w, r, http.StatusOK, languages)
+}
+
+// GetLanguage returns a language by its name or any of its aliases.
+func (s *SnippetsHandler) GetLanguage(w http.ResponseWriter, r *http.Request) {
+	language, err := s.DbQueries.GetLanguageByName(r.Context(), r.PathValue("name"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, errLanguageNotFound.Error())
+		return
+	}
+	aliases, err := s.DbQueries.GetLanguageAliases(r.Context(), language.ID)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	utilites.ResponseWithJson(w, r, http.StatusOK, newLanguage(language, aliases))
+}
+
+func (s *SnippetsHandler) CreateLanguage(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name          string        `json:"name"`
+		DisplayName   string        `json:"display_name"`
+		Aliases       []string      `json:"aliases"`
+		Extensions    []string      `json:"extensions"`
+		MimeType      string        `json:"mime_type"`
+		CommentSyntax CommentSyntax `json:"comment_syntax"`
+	}
+
+	if _, ok := s.getAdmin(w, r); !ok {
+		return
+	}
+	params := parameters{}
+	err := utilites.DecodeJsonBody(w, r, &params)
+	if err != nil {
+		return
+	}
+	if params.DisplayName == "" {
+		params.DisplayName = params.Name
+	}
+	if params.MimeType == "" {
+		params.MimeType = "text/plain"
+	}
+	language := Language{
+		Name:          params.Name,
+		DisplayName:   params.DisplayName,
+		Aliases:       params.Aliases,
+		Extensions:    params.Extensions,
+		MimeType:      params.MimeType,
+		CommentSyntax: params.CommentSyntax,
+	}
+	if err := normalizeLanguage(&language); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	created, err := s.saveLanguage(r.Context(), uuid.Nil, language)
+	if isUniqueViolation(err) {
+		utilites.ResponseWithError(w, r, http.StatusConflict, "name or alias is already used by another language")
+		return
+	}
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	s.languagesChanged()
+	utilites.ResponseWithJson(w, r, http.StatusCreated, created)
+}
+
+// UpdateLanguage changes the fields given. Aliases and extensions are
+// replaced as a whole.
+func (s *SnippetsHandler) UpdateLanguage(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name          *string        `json:"name"`
+		DisplayName   *string        `json:"display_name"`
+		Aliases       []string       `json:"aliases"`
+		Extensions    []string       `json:"extensions"`
+		MimeType      *string        `json:"mime_type"`
+		CommentSyntax *CommentSyntax `json:"comment_syntax"`
+	}
+
+	if _, ok := s.getAdmin(w, r); !ok {
+		return
+	}
+	existing, err := s.DbQueries.GetLanguageByName(r.Context(), r.PathValue("name"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, errLanguageNotFound.Error())
+		return
+	}
+	aliases, err := s.DbQueries.GetLanguageAliases(r.Context(), existing.ID)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	params := parameters{}
+	err = utilites.DecodeJsonBody(w, r, &params)
+	if err != nil {
+		return
+	}
+
+	language := newLanguage(existing, aliases)
+	if params.Name != nil {
+		language.Name = *params.Name
+	}
+	if params.DisplayName != nil {
+		language.DisplayName = *params.DisplayName
+	}
+	if params.Aliases != nil {
+		language.Aliases = params.Aliases
+	}
+	if params.Extensions != nil {
+		language.Extensions = params.Extensions
+	}
+	if params.MimeType != nil {
+		language.MimeType = *params.MimeType
+	}
+	if params.CommentSyntax != nil {
+		language.CommentSyntax = *params.CommentSyntax
+	}
+	if err := normalizeLanguage(&language); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if existing.Name == fallbackLanguage && language.Name != fallbackLanguage {
+		utilites.ResponseWithError(w, r, http.StatusConflict, errFallbackLanguage.Error())
+		return
+	}
+
+	updated, err := s.saveLanguage(r.Context(), existing.ID, language)
+	if isUniqueViolation(err) {
+		utilites.ResponseWithError(w, r, http.StatusConflict, "name or alias is already used by another language")
+		return
+	}
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	// Completions hold language names, so renames are applied to them too
+	if updated.Name != existing.Name {
+		s.Completions.RenameLanguage(existing.Name, updated.Name)
+	}
+	s.languagesChanged()
+	utilites.ResponseWithJson(w, r, http.StatusOK, updated)
+}
+
+// DeleteLanguage removes a language no snippet, file or revision is written
+// in. Languages in use are kept, since deleting them would delete the
+// snippets along with them, and so is the fallback language.
+func (s *SnippetsHandler) DeleteLanguage(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.getAdmin(w, r); !ok {
+		return
+	}
+	language, err := s.DbQueries.GetLanguageByName(r.Context(), r.PathValue("name"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, errLanguageNotFound.Error())
+		return
+	}
+	if language.Name == fallbackLanguage {
+		utilites.ResponseWithError(w, r, http.StatusConflict, errFallbackLanguage.Error())
+		return
+	}
+	deleted, err := s.DbQueries.DeleteLanguage(r.Context(), language.ID)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	if deleted == 0 {
+		utilites.ResponseWithError(w, r, http.StatusConflict, "language is used by snippets")
+		return
+	}
+	s.languagesChanged()
+	utilites.ResponseWithJson(w, r, http.StatusNoContent, "")
+}
+
+// languagesChanged refreshes what the handler keeps in memory about
+// languages after one is created, edited or deleted. The detector is
+// retrained in the background, outliving the request.
+func (s *SnippetsHandler) languagesChanged() {
+	s.languages.invalidate()
+	go func() {
+		if err := s.TrainDetector(context.Background()); err != nil {
+			log.Printf("Error training language detection. %v", err)
+		}
+	}()
+}
+
+// saveLanguage creates a language, or updates the one with the given id, and
+// stores its aliases.
+func (s *SnippetsHandler) saveLanguage(ctx context.Context, id uuid.UUID, language Language) (Language, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return Language{}, err
+	}
+	defer tx.Rollback()
+	qtx := s.DbQueries.WithTx(tx)
+
+	var saved database.Language
+	if id == uuid.Nil {
+		saved, err = qtx.CreateLanguage(ctx, database.CreateLanguageParams{
+			Name:              language.Name,
+			DisplayName:       language.DisplayName,
+			Extensions:        language.Extensions,
+			MimeType:          language.MimeType,
+			LineComment:       language.CommentSyntax.Line,
+			BlockCommentStart: language.CommentSyntax.BlockStart,
+			BlockCommentEnd:   language.CommentSyntax.BlockEnd,
+		})
+	} else {
+		saved, err = qtx.UpdateLanguage(ctx, database.UpdateLanguageParams{
+			ID:                id,
+			Name:              language.Name,
+			DisplayName:       language.DisplayName,
+			Extensions:        language.Extensions,
+			MimeType:          language.MimeType,
+			LineComment:       language.CommentSyntax.Line,
+			BlockCommentStart: language.CommentSyntax.BlockStart,
+			BlockCommentEnd:   language.CommentSyntax.BlockEnd,
+		})
+	}
+	if err != nil {
+		return Language{}, err
+	}
+	// The language's own name is stored as an alias too, so one lookup
+	// resolves both and names can't clash with other languages' aliases
+	err = qtx.DeleteLanguageAliases(ctx, saved.ID)
+	if err == nil {
+		err = qtx.SetLanguageAliases(ctx, database.SetLanguageAliasesParams{LanguageID: saved.ID, Aliases: append([]string{saved.Name}, language.Aliases...)})
+	}
+	if err != nil {
+		return Language{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return Language{}, err
+	}
+	return newLanguage(saved, language.Aliases), nil
+}
+
+// getAdmin returns the caller if they are an admin, writing the error
+// response itself when they are not.
+func (s *SnippetsHandler) getAdmin(w http.ResponseWriter, r *http.Request) (*auth.User, bool) {
+	user, err := s.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return nil, false
+	}
+	if !user.IsAdmin {
+		utilites.ResponseWithError(w, r, http.StatusForbidden, "only admins can manage languages")
+		return nil, false
+	}
+	return user, true
+}
+
+// normalizeLanguage lowercases and de-duplicates a language's name, aliases
+// and extensions, and checks every field.
+func normalizeLanguage(language *Language) error {
+	var err error
+	language.Name, err = normalizeLanguageName("name", language.Name)
+	if err != nil {
+		return err
+	}
+	if language.Name == autoLanguage {
+		return fmt.Errorf("%s is reserved for language detection", autoLanguage)
+	}
+	language.DisplayName = strings.TrimSpace(language.DisplayName)
+	if language.DisplayName == "" || len(language.DisplayName) > 100 {
+		return errors.New("display_name must be between 1 and 100 characters")
+	}
+
+	aliases := []string{}
+	for _, alias := range language.Aliases {
+		alias, err := normalizeLanguageName("alias", alias)
+		if err != nil {
+			return err
+		}
+		if alias == autoLanguage {
+			return fmt.Errorf("%s is reserved for language detection", autoLanguage)
+		}
+		if alias != language.Name && !slices.Contains(aliases, alias) {
+			aliases = append(aliases, alias)
+		}
+	}
+	if len(aliases) > maxLanguageAliases {
+		return fmt.Errorf("a language can have at most %d aliases", maxLanguageAliases)
+	}
+	language.Aliases = aliases
+
+	extensions := []string{}
+	for _, extension := range language.Extensions {
+		extension = strings.ToLower(strings.TrimSpace(extension))
+		if !strings.HasPrefix(extension, ".") {
+			extension = "." + extension
+		}
+		if _, err := normalizeLanguageName("extension", extension[1:]); err != nil {
+			return err
+		}
+		if !slices.Contains(extensions, extension) {
+			extensions = append(extensions, extension)
+		}
+	}
+	if len(extensions) > maxLanguageAliases {
+		return fmt.Errorf("a language can have at most %d extensions", maxLanguageAliases)
+	}
+	language.Extensions = extensions
+
+	mediaType, _, err := mime.ParseMediaType(language.MimeType)
+	if err != nil || !strings.Contains(mediaType, "/") {
+		return errors.New("mime_type must be a media type such as text/x-python")
+	}
+	language.MimeType = mediaType
+
+	comments := &language.CommentSyntax
+	comments.Line = strings.TrimSpace(comments.Line)
+	comments.BlockStart = strings.TrimSpace(comments.BlockStart)
+	comments.BlockEnd = strings.TrimSpace(comments.BlockEnd)
+	if len(comments.Line) > maxCommentSyntax || len(comments.BlockStart) > maxCommentSyntax || len(comments.BlockEnd) > maxCommentSyntax {
+		return fmt.Errorf("comment syntax must be at most %d characters", maxCommentSyntax)
+	}
+	if (comments.BlockStart == "") != (comments.BlockEnd == "") {
+		return errors.New("comment_syntax needs both block_start and block_end, or neither")
+	}
+	return nil
+}
+
+// normalizeLanguageName lowercases a language name, alias or extension,
+// which may only contain letters, digits and - _ . + #
+func normalizeLanguageName(field, name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || len(name) > maxLanguageName {
+		return "", fmt.Errorf("%s must be between 1 and %d characters", field, maxLanguageName)
+	}
+	for _, char := range name {
+		if !(char >= 'a' && char <= 'z') && !(char >= '0' && char <= '9') && !strings.ContainsRune("-_.+#", char) {
+			return "", fmt.Errorf("%s: %s contains invalid characters", field, name)
+		}
+	}
+	return name, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
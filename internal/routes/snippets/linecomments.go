@@ -0,0 +1,232 @@
+package snippets
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/TKyleB/snippetz/internal/diff"
+	"github.com/TKyleB/snippetz/internal/utilites"
+	"github.com/google/uuid"
+)
+
+// LineAnchor ties a comment to lines StartLine through EndLine (one-based,
+// inclusive) of a file as it was in Revision. CurrentStartLine and
+// CurrentEndLine locate the same lines in the latest revision; they are nil
+// and Outdated is set once an edit has changed or removed any of them.
+type LineAnchor struct {
+	Revision         int32  `json:"revision"`
+	File             string `json:"file"`
+	StartLine        int32  `json:"start_line"`
+	EndLine          int32  `json:"end_line"`
+	CurrentStartLine *int32 `json:"current_start_line"`
+	CurrentEndLine   *int32 `json:"current_end_line"`
+	Outdated         bool   `json:"outdated"`
+}
+
+func newLineAnchor(row database.GetSnippetCommentRow) *LineAnchor {
+	if !row.StartLine.Valid {
+		return nil
+	}
+	anchor := &LineAnchor{
+		Revision:  row.Revision.Int32,
+		File:      row.FileName.String,
+		StartLine: row.StartLine.Int32,
+		EndLine:   row.EndLine.Int32,
+		Outdated:  row.Outdated,
+	}
+	if row.CurrentStartLine.Valid && row.CurrentEndLine.Valid {
+		anchor.CurrentStartLine = &row.CurrentStartLine.Int32
+		anchor.CurrentEndLine = &row.CurrentEndLine.Int32
+	}
+	return anchor
+}
+
+// GetSnippetLineComments returns the line-anchored comment threads of a
+// snippet, including outdated ones, so clients can place them in the gutter.
+func (s *SnippetsHandler) GetSnippetLineComments(w http.ResponseWriter, r *http.Request) {
+	s.listComments(w, r, true)
+}
+
+func (s *SnippetsHandler) CreateSnippetLineComment(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body      string  `json:"body"`
+		Revision  *int32  `json:"revision"`
+		File      *string `json:"file"`
+		StartLine int32   `json:"start_line"`
+		EndLine   int32   `json:"end_line"`
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	user, err := s.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if _, err := s.getVisibleSnippet(r, id); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	params := parameters{}
+	err = utilites.DecodeJsonBody(w, r, &params)
+	if err != nil {
+		return
+	}
+	body, err := validateCommentBody(params.Body)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Comments are made against the latest revision unless one is named
+	var rev int32
+	if params.Revision != nil {
+		rev = *params.Revision
+	} else {
+		revisions, err := s.DbQueries.GetSnippetRevisions(r.Context(), id)
+		if err != nil || len(revisions) == 0 {
+			utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+			return
+		}
+		rev = revisions[0].Revision
+	}
+	revision, err := s.DbQueries.GetSnippetRevision(r.Context(), database.GetSnippetRevisionParams{SnippetID: id, Revision: rev})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "revision not found")
+		return
+	}
+	revFiles, err := revisionFiles(revision.Files)
+	if err != nil || len(revFiles) == 0 {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	fileName := revFiles[0].Name
+	if params.File != nil {
+		fileName = *params.File
+	}
+	file, ok := findFile(revFiles, fileName)
+	if !ok {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "file not found in revision")
+		return
+	}
+	lineCount := int32(len(diff.SplitLines(file.Text)))
+	if params.StartLine < 1 || params.EndLine < params.StartLine || params.EndLine > lineCount {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "start_line and end_line must be a range of lines in the file")
+		return
+	}
+
+	currentFiles, err := getSnippetFiles(r.Context(), s.DbQueries, id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	createParams := database.CreateSnippetCommentParams{
+		SnippetID: id,
+		UserID:    user.ID,
+		Body:      body,
+		Revision:  sql.NullInt32{Int32: rev, Valid: true},
+		FileName:  sql.NullString{String: fileName, Valid: true},
+		StartLine: sql.NullInt32{Int32: params.StartLine, Valid: true},
+		EndLine:   sql.NullInt32{Int32: params.EndLine, Valid: true},
+	}
+	if start, end, ok := remapRange(mapFileLines(revFiles, currentFiles, fileName), params.StartLine, params.EndLine); ok {
+		createParams.CurrentStartLine = sql.NullInt32{Int32: start, Valid: true}
+		createParams.CurrentEndLine = sql.NullInt32{Int32: end, Valid: true}
+	} else {
+		createParams.Outdated = true
+	}
+
+	comment, err := s.DbQueries.CreateSnippetComment(r.Context(), createParams)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	utilites.ResponseWithJson(w, r, http.StatusCreated, newComment(database.GetSnippetCommentRow(comment)))
+}
+
+// remapLineComments moves the anchors of a snippet's line comments from the
+// files before an edit to the files after it, marking anchors whose lines
+// changed as outdated. It runs inside the edit's transaction.
+func remapLineComments(ctx context.Context, qtx *database.Queries, snippetID uuid.UUID, from, to []SnippetFile) error {
+	comments, err := qtx.GetSnippetComments(ctx, database.GetSnippetCommentsParams{SnippetID: snippetID, Anchored: true})
+	if err != nil {
+		return err
+	}
+	// Each file is diffed once, however many comments are anchored in it
+	mappings := make(map[string][]int)
+	for _, comment := range comments {
+		if comment.Outdated {
+			continue
+		}
+		mapping, ok := mappings[comment.FileName.String]
+		if !ok {
+			mapping = mapFileLines(from, to, comment.FileName.String)
+			mappings[comment.FileName.String] = mapping
+		}
+		params := database.UpdateSnippetCommentAnchorParams{ID: comment.ID}
+		start, end, ok := remapRange(mapping, comment.CurrentStartLine.Int32, comment.CurrentEndLine.Int32)
+		if ok {
+			if start == comment.CurrentStartLine.Int32 && end == comment.CurrentEndLine.Int32 {
+				continue
+			}
+			params.CurrentStartLine = sql.NullInt32{Int32: start, Valid: true}
+			params.CurrentEndLine = sql.NullInt32{Int32: end, Valid: true}
+		} else {
+			params.Outdated = true
+		}
+		if err := qtx.UpdateSnippetCommentAnchor(ctx, params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapFileLines maps every line of a file in from to where it ended up in
+// to, or -1 for lines that were removed. It returns nil when the file is in
+// only one of them.
+func mapFileLines(from, to []SnippetFile, fileName string) []int {
+	fromFile, ok := findFile(from, fileName)
+	if !ok {
+		return nil
+	}
+	toFile, ok := findFile(to, fileName)
+	if !ok {
+		return nil
+	}
+	return diff.MapLines(diff.SplitLines(fromFile.Text), diff.SplitLines(toFile.Text))
+}
+
+// remapRange finds where lines start through end of a file ended up, given
+// its mapping from mapFileLines. It fails when the file is gone or any line
+// in the range was changed, removed or had lines inserted between it and its
+// neighbours.
+func remapRange(mapping []int, start, end int32) (int32, int32, bool) {
+	if start < 1 || int(end) > len(mapping) {
+		return 0, 0, false
+	}
+	first := mapping[start-1]
+	if first < 0 {
+		return 0, 0, false
+	}
+	for line := start; line < end; line++ {
+		if mapping[line] != mapping[line-1]+1 {
+			return 0, 0, false
+		}
+	}
+	return int32(first) + 1, int32(first) + 1 + end - start, true
+}
+
+func findFile(files []SnippetFile, name string) (SnippetFile, bool) {
+	for _, file := range files {
+		if file.Name == name {
+			return file, true
+		}
+	}
+	return SnippetFile{}, false
+}
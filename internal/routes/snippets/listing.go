@@ -0,0 +1,413 @@
+package snippets
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/TKyleB/snippetz/internal/auth"
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/TKyleB/snippetz/internal/listing"
+	searchquery "github.com/TKyleB/snippetz/internal/search"
+	"github.com/TKyleB/snippetz/internal/utilites"
+	"github.com/google/uuid"
+)
+
+var errListingNeedsAuth = errors.New("listing your own snippets requires authentication")
+
+// listRequest is a parsed request to list snippets. The same query parameters
+// drive GET /api/snippets and saved searches.
+type listRequest struct {
+	params       listing.ListSnippetsParams
+	regex        *searchquery.Regex
+	cursor       *cursor
+	withTotal    bool
+	withFacets   bool
+	searchString string
+}
+
+// parseListing parses the query parameters of a snippet listing on behalf of
+// viewer, who is nil when signed out.
+func (s *SnippetsHandler) parseListing(ctx context.Context, query url.Values, viewer *auth.User) (*listRequest, error) {
+	// Set-up for pagination
+	var pageCursor *cursor
+	withTotal := true
+	withFacets := true
+	limit := int32(5)
+	offset := int32(0)
+
+	var viewerID uuid.NullUUID
+	var ownerID uuid.NullUUID
+	var languages []string
+	var usernames []string
+	var createdAfter sql.NullTime
+	var createdBefore sql.NullTime
+	var updatedSince sql.NullTime
+	var hasForks sql.NullBool
+	var minStars sql.NullInt32
+	var search sql.NullString
+	var fuzzy sql.NullString
+	var substring sql.NullString
+	var regexLiteral sql.NullString
+	var regex *searchquery.Regex
+	var tags []string
+	matchAllTags := true
+	sort := "created"
+	ascending := false
+	mineString := query.Get("mine")
+	languageStrings := query["language"]
+	usernameStrings := query["username"]
+	createdAfterString := query.Get("created_after")
+	createdBeforeString := query.Get("created_before")
+	updatedSinceString := query.Get("updated_since")
+	hasForksString := query.Get("has_forks")
+	minStarsString := query.Get("min_stars")
+	searchString := query.Get("q")
+	limitString := query.Get("limit")
+	offsetString := query.Get("offset")
+	tagStrings := query["tag"]
+	tagModeString := query.Get("tag_mode")
+	sortString := query.Get("sort")
+	orderString := query.Get("order")
+	modeString := query.Get("mode")
+	regexString := query.Get("regex")
+	cursorString := query.Get("cursor")
+	totalString := query.Get("total")
+	facetsString := query.Get("facets")
+
+	if limitString != "" {
+		parseLimit, err := ParseIntParam("limit", limitString, 1, MaxLimit)
+		if err != nil {
+			return nil, err
+		}
+		limit = parseLimit
+	}
+	if offsetString != "" {
+		parseOffset, err := ParseIntParam("offset", offsetString, 0, math.MaxInt32)
+		if err != nil {
+			return nil, err
+		}
+		offset = parseOffset
+	}
+	mine := false
+	if mineString != "" {
+		var err error
+		if mine, err = ParseBoolParam("mine", mineString); err != nil {
+			return nil, err
+		}
+	}
+	// Listing your own snippets is the only way to see unlisted and private ones
+	if mine {
+		if viewer == nil {
+			return nil, errListingNeedsAuth
+		}
+		ownerID = uuid.NullUUID{UUID: viewer.ID, Valid: true}
+	}
+	if viewer != nil {
+		viewerID = uuid.NullUUID{UUID: viewer.ID, Valid: true}
+	}
+	for _, name := range languageStrings {
+		name = strings.TrimSpace(name)
+		language, err := s.DbQueries.GetLanguageByName(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("language: %s is not currently supported", name)
+		}
+		languages = append(languages, language.Name)
+	}
+	for _, username := range usernameStrings {
+		if username = strings.TrimSpace(username); username == "" {
+			return nil, errors.New("username must not be empty")
+		}
+		usernames = append(usernames, username)
+	}
+	if createdAfterString != "" {
+		t, err := parseTimeParam("created_after", createdAfterString)
+		if err != nil {
+			return nil, err
+		}
+		createdAfter = sql.NullTime{Time: t, Valid: true}
+	}
+	if createdBeforeString != "" {
+		t, err := parseTimeParam("created_before", createdBeforeString)
+		if err != nil {
+			return nil, err
+		}
+		createdBefore = sql.NullTime{Time: t, Valid: true}
+	}
+	if updatedSinceString != "" {
+		t, err := parseTimeParam("updated_since", updatedSinceString)
+		if err != nil {
+			return nil, err
+		}
+		updatedSince = sql.NullTime{Time: t, Valid: true}
+	}
+	if hasForksString != "" {
+		b, err := ParseBoolParam("has_forks", hasForksString)
+		if err != nil {
+			return nil, err
+		}
+		hasForks = sql.NullBool{Bool: b, Valid: true}
+	}
+	if minStarsString != "" {
+		n, err := ParseIntParam("min_stars", minStarsString, 0, math.MaxInt32)
+		if err != nil {
+			return nil, err
+		}
+		minStars = sql.NullInt32{Int32: n, Valid: true}
+	}
+	switch modeString {
+	case "", "fts":
+	case "fuzzy", "substring":
+	default:
+		return nil, errors.New("mode must be fts, fuzzy or substring")
+	}
+	// Fuzzy and substring searches match the raw text against titles and code
+	// through trigram indexes instead of parsing it as a query
+	if searchString != "" && modeString == "fuzzy" {
+		fuzzy.Scan(searchString)
+	} else if searchString != "" && modeString == "substring" {
+		substring.Scan(searchString)
+	} else if searchString != "" {
+		query, err := searchquery.Parse(searchString)
+		if err != nil {
+			return nil, err
+		}
+		if query.Text != "" {
+			search.Scan(query.Text)
+		}
+		// Unknown lang: qualifiers are kept so they match nothing rather than fail
+		var queryLanguages []string
+		for _, name := range query.Languages {
+			if language, err := s.DbQueries.GetLanguageByName(ctx, name); err == nil {
+				name = language.Name
+			}
+			queryLanguages = append(queryLanguages, name)
+		}
+		// Qualifiers narrow the language and username parameters rather than
+		// adding to them
+		if queryLanguages != nil {
+			languages = narrowValues(languages, queryLanguages)
+		}
+		if query.Usernames != nil {
+			usernames = narrowValues(usernames, query.Usernames)
+		}
+		tagStrings = append(tagStrings, query.Tags...)
+		if query.CreatedAfter != nil {
+			createdAfter = narrowAfter(createdAfter, *query.CreatedAfter)
+		}
+		if query.CreatedBefore != nil {
+			createdBefore = narrowBefore(createdBefore, *query.CreatedBefore)
+		}
+	}
+	if regexString != "" {
+		var err error
+		regex, err = searchquery.CompileRegex(regexString)
+		if err != nil {
+			return nil, err
+		}
+		if regex.Literal != "" {
+			regexLiteral.Scan(regex.Literal)
+		}
+	}
+	if len(tagStrings) > 0 {
+		for _, tag := range tagStrings {
+			tags = append(tags, strings.ToLower(strings.TrimSpace(tag)))
+		}
+		tags = slices.Compact(slices.Sorted(slices.Values(tags)))
+	}
+	switch tagModeString {
+	case "", "all":
+	case "any":
+		matchAllTags = false
+	default:
+		return nil, errors.New("tag_mode must be all or any")
+	}
+	// Searches are ordered by relevance unless another order is asked for
+	switch sortString {
+	case "", "relevance":
+		if search.Valid {
+			sort = "relevance"
+		} else if fuzzy.Valid || substring.Valid {
+			sort = "similarity"
+		}
+	case "created", "updated", "title", "stars", "views", "forks":
+		sort = sortString
+	default:
+		return nil, errors.New("sort must be created, updated, title, relevance, stars, views or forks")
+	}
+	// Titles read A to Z by default, everything else highest or newest first
+	switch orderString {
+	case "":
+		ascending = sort == "title"
+	case "asc":
+		ascending = true
+	case "desc":
+	default:
+		return nil, errors.New("order must be asc or desc")
+	}
+	if totalString != "" {
+		var err error
+		if withTotal, err = ParseBoolParam("total", totalString); err != nil {
+			return nil, err
+		}
+	}
+	if facetsString != "" {
+		var err error
+		if withFacets, err = ParseBoolParam("facets", facetsString); err != nil {
+			return nil, err
+		}
+	}
+	if cursorString != "" {
+		c, err := decodeCursor(cursorString)
+		if err == nil && (c.Sort != sort || c.Ascending != ascending) {
+			err = errInvalidCursor
+		}
+		if err != nil {
+			return nil, err
+		}
+		pageCursor = &c
+	}
+
+	params := listing.ListSnippetsParams{Limit: limit, Offset: offset, ViewerID: viewerID, Search: search, Sort: sort, Ascending: ascending, Fuzzy: fuzzy, Substring: substring, OwnerID: ownerID, Languages: languages, Usernames: usernames, CreatedAfter: createdAfter, CreatedBefore: createdBefore, UpdatedSince: updatedSince, HasForks: hasForks, MinStars: minStars, RegexLiteral: regexLiteral, Tags: tags, MatchAllTags: matchAllTags}
+	return &listRequest{params: params, regex: regex, cursor: pageCursor, withTotal: withTotal, withFacets: withFacets, searchString: searchString}, nil
+}
+
+// facetParams are the listing's filters without its paging and sorting.
+func (l *listRequest) facetParams() database.GetSnippetFacetsParams {
+	p := l.params
+	return database.GetSnippetFacetsParams{OwnerID: p.OwnerID, Languages: p.Languages, Usernames: p.Usernames, CreatedAfter: p.CreatedAfter, CreatedBefore: p.CreatedBefore, UpdatedSince: p.UpdatedSince, HasForks: p.HasForks, MinStars: p.MinStars, Search: p.Search, Fuzzy: p.Fuzzy, Substring: p.Substring, RegexLiteral: p.RegexLiteral, Tags: p.Tags, MatchAllTags: p.MatchAllTags}
+}
+
+// countListing counts every snippet a listing matches in total, and by facet
+// unless the listing leaves facets out.
+func (s *SnippetsHandler) countListing(ctx context.Context, l *listRequest) (*Facets, int32, error) {
+	if l.regex != nil {
+		facets, total, err := s.regexFacets(ctx, l.params, l.regex)
+		if !l.withFacets {
+			facets = nil
+		}
+		return facets, total, err
+	}
+	if !l.withFacets {
+		total, err := s.DbQueries.CountSnippets(ctx, database.CountSnippetsParams(l.facetParams()))
+		return nil, int32(total), err
+	}
+	facets, err := s.getFacets(ctx, l.facetParams())
+	if err != nil {
+		return nil, 0, err
+	}
+	// Every snippet has exactly one language
+	var total int32
+	for _, n := range facets.Languages {
+		total += int32(n)
+	}
+	return facets, total, nil
+}
+
+// writeListing fetches and writes one page of a listing.
+func (s *SnippetsHandler) writeListing(w http.ResponseWriter, r *http.Request, l *listRequest) {
+	var dbSnippets []listing.ListSnippetsRow
+	var lineMatches [][]searchquery.LineMatch
+	var more bool
+	var err error
+	if l.regex != nil {
+		dbSnippets, lineMatches, more, err = s.regexPage(r.Context(), l.params, l.regex, l.cursor)
+	} else {
+		dbSnippets, more, err = s.cursorPage(r, l.params, l.cursor)
+	}
+	if err != nil {
+		writeListError(w, r, err)
+		return
+	}
+
+	// The total and facets cover every matching snippet, so they are the
+	// expensive part of a listing and can be skipped
+	var count *int32
+	var facets *Facets
+	if l.withTotal {
+		var total int32
+		facets, total, err = s.countListing(r.Context(), l)
+		if err != nil {
+			writeListError(w, r, err)
+			return
+		}
+		count = &total
+	}
+
+	var snippets []Snippet
+	for i, snippet := range dbSnippets {
+		var highlights *Highlights
+		if l.params.Search.Valid {
+			highlights = &Highlights{Title: snippet.TitleHighlight, Description: snippet.DescriptionHighlight, Code: snippet.CodeHighlight}
+		}
+		snippets = append(snippets, Snippet{
+			ID:           snippet.ID,
+			CreatedAt:    snippet.CreatedAt,
+			UpdatedAt:    snippet.UpdatedAt,
+			Language:     snippet.Language,
+			UserID:       snippet.UserID,
+			SnippetText:  snippet.SnippetText,
+			UserName:     snippet.Username,
+			SnippetDesc:  snippet.SnippetDescription,
+			SnippetTitle: snippet.SnippetTitle,
+			Visibility:   snippet.Visibility,
+			ForkedFromID: nullUUIDPtr(snippet.ForkedFromID),
+			ForkCount:    snippet.ForkCount,
+			StarCount:    snippet.StarCount,
+			ViewCount:    snippet.ViewCount,
+			StarredByMe:  snippet.StarredByMe,
+			Tags:         snippet.Tags,
+			Highlights:   highlights,
+			Score:        snippet.Similarity,
+		})
+		if l.regex != nil {
+			snippets[i].Matches = lineMatches[i]
+		}
+	}
+
+	// Paging backwards always leaves a next page, the one paged back from,
+	// and paging forwards leaves a previous one once past the first page
+	var next *string
+	var previous *string
+	if len(dbSnippets) > 0 {
+		first, last := dbSnippets[0], dbSnippets[len(dbSnippets)-1]
+		backwards := l.cursor != nil && l.cursor.Before
+		if more || backwards {
+			next = pageURL(r, newCursor(l.params.Sort, l.params.Ascending, last, false))
+		}
+		if backwards && more || !backwards && (l.cursor != nil || l.params.Offset > 0) {
+			previous = pageURL(r, newCursor(l.params.Sort, l.params.Ascending, first, true))
+		}
+	}
+	var results = Results{}
+	results.Snippets = snippets
+	if facets != nil {
+		results.Languages = facets.Languages
+		results.Facets = facets
+	}
+	response := SnippetsResponse{Count: count, Next: next, Previous: previous, Results: results}
+	if l.params.Search.Valid && l.cursor == nil && l.params.Offset == 0 && len(dbSnippets) == 0 {
+		response.Suggestion = s.suggestQuery(r.Context(), l.searchString)
+	}
+
+	utilites.ResponseWithJson(w, r, http.StatusOK, &response)
+}
+
+// writeListError responds to an error listing snippets, telling a regex that
+// ran too long or too broadly apart from other failures.
+func writeListError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, errRegexTimeout):
+		utilites.ResponseWithError(w, r, http.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, errRegexTooBroad):
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+	default:
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+	}
+}
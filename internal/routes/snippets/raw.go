@@ -0,0 +1,90 @@
+package snippets
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// prefersPlainText reports whether the Accept header ranks text/plain above
+// application/json. JSON wins ties and requests without an Accept header.
+func prefersPlainText(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return false
+	}
+
+	textQ, jsonQ := -1.0, -1.0
+	textSpecificity, jsonSpecificity := -1, -1
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, param := range fields[1:] {
+			key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if found && strings.EqualFold(key, "q") {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		// The most specific media range matching a type decides its quality
+		switch mediaType {
+		case "text/plain":
+			textQ, textSpecificity = q, 2
+		case "application/json":
+			jsonQ, jsonSpecificity = q, 2
+		case "text/*":
+			if textSpecificity < 1 {
+				textQ, textSpecificity = q, 1
+			}
+		case "application/*":
+			if jsonSpecificity < 1 {
+				jsonQ, jsonSpecificity = q, 1
+			}
+		case "*/*":
+			if textSpecificity < 0 {
+				textQ, textSpecificity = q, 0
+			}
+			if jsonSpecificity < 0 {
+				jsonQ, jsonSpecificity = q, 0
+			}
+		}
+	}
+	return textQ > 0 && textQ > jsonQ
+}
+
+// rawFileName builds a download name from a snippet's title and the extension of its language.
+func rawFileName(title, extension string) string {
+	var sb strings.Builder
+	lastDash := true
+	for _, char := range strings.ToLower(title) {
+		if (char >= 'a' && char <= 'z') || (char >= '0' && char <= '9') {
+			sb.WriteRune(char)
+			lastDash = false
+		} else if !lastDash {
+			sb.WriteRune('-')
+			lastDash = true
+		}
+		if sb.Len() >= 64 {
+			break
+		}
+	}
+	name := strings.Trim(sb.String(), "-")
+	if name == "" {
+		return defaultFileName(extension)
+	}
+	return name + extension
+}
+
+// writeRaw writes code as a plain text response. Snippet text is stored in
+// Postgres as UTF-8 so that is always the charset.
+func writeRaw(w http.ResponseWriter, fileName, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, fileName))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(text))
+}
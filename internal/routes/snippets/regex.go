@@ -0,0 +1,140 @@
+package snippets
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/TKyleB/snippetz/internal/listing"
+	searchquery "github.com/TKyleB/snippetz/internal/search"
+	"github.com/google/uuid"
+)
+
+const (
+	regexTimeout       = 3 * time.Second
+	regexBatchSize     = 200
+	regexMaxCandidates = 5000
+	regexContextLines  = 2
+)
+
+var (
+	errRegexTimeout  = errors.New("regex search timed out, try a more specific pattern or add filters")
+	errRegexTooBroad = errors.New("regex matches too many snippets, include a literal of at least 3 characters or add filters")
+)
+
+// regexScan runs a regex over every file of every snippet params selects, in
+// order, and calls visit with each snippet it matches and its matching lines
+// until visit returns false. The database narrows candidates to snippets with
+// a file containing the regex's literal, and the regex runs here, so the scan
+// is bounded by both a timeout and a number of candidates.
+func (s *SnippetsHandler) regexScan(ctx context.Context, params listing.ListSnippetsParams, regex *searchquery.Regex, visit func(listing.ListSnippetsRow, []searchquery.LineMatch) bool) error {
+	ctx, cancel := context.WithTimeout(ctx, regexTimeout)
+	defer cancel()
+
+	params.Limit = regexBatchSize
+	params.Offset = 0
+	scanned := 0
+	for {
+		if scanned >= regexMaxCandidates {
+			return errRegexTooBroad
+		}
+		rows, err := listing.ListSnippets(ctx, s.DB, params)
+		if err != nil {
+			if ctx.Err() != nil {
+				return errRegexTimeout
+			}
+			return err
+		}
+		files, err := s.snippetFiles(ctx, rows)
+		if err != nil {
+			if ctx.Err() != nil {
+				return errRegexTimeout
+			}
+			return err
+		}
+		for _, row := range rows {
+			if ctx.Err() != nil {
+				return errRegexTimeout
+			}
+			var matches []searchquery.LineMatch
+			for _, file := range files[row.ID] {
+				matches = append(matches, regex.MatchLines(file.FileName, file.FileText, regexContextLines)...)
+			}
+			if len(matches) > 0 && !visit(row, matches) {
+				return nil
+			}
+		}
+		if len(rows) < regexBatchSize {
+			return nil
+		}
+		scanned += len(rows)
+		newCursor(params.Sort, params.Ascending, rows[len(rows)-1], false).apply(&params)
+	}
+}
+
+// snippetFiles fetches the files of a batch of snippets, in order, by snippet.
+func (s *SnippetsHandler) snippetFiles(ctx context.Context, rows []listing.ListSnippetsRow) (map[uuid.UUID][]database.GetFilesOfSnippetsRow, error) {
+	ids := make([]string, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID.String()
+	}
+	dbFiles, err := s.DbQueries.GetFilesOfSnippets(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[uuid.UUID][]database.GetFilesOfSnippetsRow, len(rows))
+	for _, file := range dbFiles {
+		files[file.SnippetID] = append(files[file.SnippetID], file)
+	}
+	return files, nil
+}
+
+// regexPage finds the page of snippets the regex matches after c, or after
+// skipping offset matches when there is no cursor, and reports whether more
+// matches follow.
+func (s *SnippetsHandler) regexPage(ctx context.Context, params listing.ListSnippetsParams, regex *searchquery.Regex, c *cursor) ([]listing.ListSnippetsRow, [][]searchquery.LineMatch, bool, error) {
+	skip := params.Offset
+	if c != nil {
+		c.apply(&params)
+		params.Ascending = params.Ascending != c.Before
+		skip = 0
+	}
+	var rows []listing.ListSnippetsRow
+	var rowMatches [][]searchquery.LineMatch
+	more := false
+	err := s.regexScan(ctx, params, regex, func(row listing.ListSnippetsRow, matches []searchquery.LineMatch) bool {
+		if skip > 0 {
+			skip--
+			return true
+		}
+		if int32(len(rows)) == params.Limit {
+			more = true
+			return false
+		}
+		rows = append(rows, row)
+		rowMatches = append(rowMatches, matches)
+		return true
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if c != nil && c.Before {
+		slices.Reverse(rows)
+		slices.Reverse(rowMatches)
+	}
+	return rows, rowMatches, more, nil
+}
+
+// regexFacets counts every snippet the regex matches.
+func (s *SnippetsHandler) regexFacets(ctx context.Context, params listing.ListSnippetsParams, regex *searchquery.Regex) (*Facets, int32, error) {
+	facets := newFacets()
+	var count int32
+	err := s.regexScan(ctx, params, regex, func(row listing.ListSnippetsRow, _ []searchquery.LineMatch) bool {
+		facets.count(row)
+		count++
+		return true
+	})
+	return facets, count, err
+}
@@ -0,0 +1,333 @@
+package snippets
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/TKyleB/snippetz/internal/diff"
+	"github.com/TKyleB/snippetz/internal/utilites"
+	"github.com/google/uuid"
+)
+
+type Revision struct {
+	Revision     int32         `json:"revision"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UserID       uuid.UUID     `json:"author_id"`
+	UserName     string        `json:"username"`
+	Language     string        `json:"language"`
+	SnippetText  string        `json:"snippet_text,omitempty"`
+	SnippetDesc  string        `json:"snippet_desc"`
+	SnippetTitle string        `json:"snippet_title"`
+	Files        []SnippetFile `json:"files,omitempty"`
+}
+
+type RevisionDiff struct {
+	From int32  `json:"from"`
+	To   int32  `json:"to"`
+	Diff string `json:"diff"`
+}
+
+func parseRevision(revString string) (int32, error) {
+	rev, err := strconv.ParseInt(revString, 10, 32)
+	if err != nil || rev < 1 {
+		return 0, errors.New("invalid revision")
+	}
+	return int32(rev), nil
+}
+
+func (s *SnippetsHandler) GetSnippetRevisions(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	if _, err := s.getVisibleSnippet(r, id); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	dbRevisions, err := s.DbQueries.GetSnippetRevisions(r.Context(), id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	revisions := []Revision{}
+	for _, revision := range dbRevisions {
+		revisions = append(revisions, Revision{
+			Revision:     revision.Revision,
+			CreatedAt:    revision.CreatedAt,
+			UserID:       revision.UserID,
+			UserName:     revision.Username,
+			Language:     revision.Language,
+			SnippetDesc:  revision.SnippetDescription,
+			SnippetTitle: revision.SnippetTitle,
+		})
+	}
+	utilites.ResponseWithJson(w, r, http.StatusOK, &revisions)
+}
+
+func (s *SnippetsHandler) GetSnippetRevision(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	rev, err := parseRevision(r.PathValue("rev"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := s.getVisibleSnippet(r, id); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	revision, err := s.DbQueries.GetSnippetRevision(r.Context(), database.GetSnippetRevisionParams{SnippetID: id, Revision: rev})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, "revision not found")
+		return
+	}
+	files, err := revisionFiles(revision.Files)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	utilites.ResponseWithJson(w, r, http.StatusOK, Revision{
+		Revision:     revision.Revision,
+		CreatedAt:    revision.CreatedAt,
+		UserID:       revision.UserID,
+		UserName:     revision.Username,
+		Language:     revision.Language,
+		SnippetText:  revision.SnippetText,
+		SnippetDesc:  revision.SnippetDescription,
+		SnippetTitle: revision.SnippetTitle,
+		Files:        files,
+	})
+}
+
+func (s *SnippetsHandler) GetSnippetDiff(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	from, err := parseRevision(r.URL.Query().Get("from"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid from revision")
+		return
+	}
+	to, err := parseRevision(r.URL.Query().Get("to"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid to revision")
+		return
+	}
+	if _, err := s.getVisibleSnippet(r, id); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	fromRevision, err := s.DbQueries.GetSnippetRevision(r.Context(), database.GetSnippetRevisionParams{SnippetID: id, Revision: from})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, fmt.Sprintf("revision %d not found", from))
+		return
+	}
+	toRevision, err := s.DbQueries.GetSnippetRevision(r.Context(), database.GetSnippetRevisionParams{SnippetID: id, Revision: to})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, fmt.Sprintf("revision %d not found", to))
+		return
+	}
+
+	fromFiles, err := revisionFiles(fromRevision.Files)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	toFiles, err := revisionFiles(toRevision.Files)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+
+	unified := diffFiles(fromFiles, toFiles)
+	utilites.ResponseWithJson(w, r, http.StatusOK, RevisionDiff{From: from, To: to, Diff: unified})
+}
+
+func (s *SnippetsHandler) RestoreSnippetRevision(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	rev, err := parseRevision(r.PathValue("rev"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	user, err := s.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	snippet, err := s.DbQueries.GetSnippetById(r.Context(), id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, "snippet not found")
+		return
+	}
+	if snippet.UserID != user.ID {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, "")
+		return
+	}
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != "*" {
+		expectedUpdatedAt, err := parseETag(ifMatch)
+		if err != nil || !expectedUpdatedAt.Equal(snippet.UpdatedAt) {
+			w.Header().Set("ETag", snippetETag(snippet.UpdatedAt))
+			utilites.ResponseWithError(w, r, http.StatusPreconditionFailed, "snippet has been modified since it was fetched")
+			return
+		}
+	}
+
+	revision, err := s.DbQueries.GetSnippetRevision(r.Context(), database.GetSnippetRevisionParams{SnippetID: id, Revision: rev})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, "revision not found")
+		return
+	}
+
+	tx, err := s.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	defer tx.Rollback()
+	qtx := s.DbQueries.WithTx(tx)
+
+	previousFiles, err := getSnippetFiles(r.Context(), qtx, id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+
+	// Restoring writes the old content as a new head so the history stays linear
+	err = qtx.DeleteSnippetFiles(r.Context(), id)
+	if err == nil {
+		err = qtx.RestoreSnippetFiles(r.Context(), database.RestoreSnippetFilesParams{SnippetID: id, Revision: rev})
+	}
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	updated, err := qtx.UpdateSnippet(r.Context(), database.UpdateSnippetParams{
+		LanguageID:         uuid.NullUUID{UUID: revision.LanguageID, Valid: true},
+		SnippetTitle:       sql.NullString{String: revision.SnippetTitle, Valid: true},
+		SnippetDescription: sql.NullString{String: revision.SnippetDescription, Valid: true},
+		SnippetText:        sql.NullString{String: revision.SnippetText, Valid: true},
+		ID:                 id,
+		UpdatedAt:          snippet.UpdatedAt,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		utilites.ResponseWithError(w, r, http.StatusPreconditionFailed, "snippet has been modified since it was fetched")
+		return
+	}
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	if err := indexSnippetCode(r.Context(), qtx, id); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	if err := qtx.CreateSnippetRevision(r.Context(), id); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	files, err := getSnippetFiles(r.Context(), qtx, id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	if err := remapLineComments(r.Context(), qtx, id, previousFiles, files); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	tags, err := qtx.GetSnippetTags(r.Context(), id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	starred, err := qtx.IsSnippetStarred(r.Context(), database.IsSnippetStarredParams{UserID: user.ID, SnippetID: id})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+
+	w.Header().Set("ETag", snippetETag(updated.UpdatedAt))
+	saved := Snippet{
+		ID:           updated.ID,
+		CreatedAt:    updated.CreatedAt,
+		UpdatedAt:    updated.UpdatedAt,
+		Language:     updated.Language,
+		UserID:       updated.UserID,
+		SnippetText:  updated.SnippetText,
+		SnippetDesc:  updated.SnippetDescription,
+		SnippetTitle: updated.SnippetTitle,
+		Visibility:   updated.Visibility,
+		ForkedFromID: nullUUIDPtr(updated.ForkedFromID),
+		ForkCount:    int64(updated.ForkCount),
+		StarCount:    updated.StarCount,
+		ViewCount:    updated.ViewCount,
+		StarredByMe:  starred,
+		UserName:     updated.Username,
+		Files:        files,
+		Tags:         tags,
+	}
+	s.snippetSaved(saved)
+	utilites.ResponseWithJson(w, r, http.StatusOK, saved)
+}
+
+// diffFiles renders a unified diff for every file added, removed or changed
+// between two sets of files, in the order they appear.
+func diffFiles(from, to []SnippetFile) string {
+	fromText := make(map[string]string)
+	for _, file := range from {
+		fromText[file.Name] = file.Text
+	}
+	toText := make(map[string]string)
+	for _, file := range to {
+		toText[file.Name] = file.Text
+	}
+
+	var names []string
+	for _, file := range from {
+		names = append(names, file.Name)
+	}
+	for _, file := range to {
+		if _, ok := fromText[file.Name]; !ok {
+			names = append(names, file.Name)
+		}
+	}
+
+	var sb strings.Builder
+	for _, name := range names {
+		oldText, inFrom := fromText[name]
+		newText, inTo := toText[name]
+		fromName, toName := "a/"+name, "b/"+name
+		if !inFrom {
+			fromName = "/dev/null"
+		}
+		if !inTo {
+			toName = "/dev/null"
+		}
+		sb.WriteString(diff.Unified(fromName, toName, oldText, newText, 3))
+	}
+	return sb.String()
+}
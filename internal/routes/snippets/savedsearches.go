@@ -0,0 +1,267 @@
+package snippets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/TKyleB/snippetz/internal/auth"
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/TKyleB/snippetz/internal/utilites"
+	"github.com/google/uuid"
+)
+
+const (
+	maxSavedSearchName = 100
+	savedSearchBatch   = 100
+)
+
+var errSavedSearchNotFound = errors.New("saved search not found")
+
+// pagingParams belong to one request for a listing rather than to the search
+// itself, so they are not saved.
+var pagingParams = []string{"limit", "offset", "cursor", "total", "facets"}
+
+type SavedSearch struct {
+	ID            uuid.UUID  `json:"id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Name          string     `json:"name"`
+	Query         string     `json:"query"`
+	LastCheckedAt time.Time  `json:"last_checked_at"`
+	NewCount      int32      `json:"new_count"`
+	CountedAt     *time.Time `json:"counted_at"`
+}
+
+func newSavedSearch(search database.SavedSearch) SavedSearch {
+	saved := SavedSearch{
+		ID:            search.ID,
+		CreatedAt:     search.CreatedAt,
+		UpdatedAt:     search.UpdatedAt,
+		Name:          search.Name,
+		Query:         search.Query,
+		LastCheckedAt: search.LastCheckedAt,
+		NewCount:      search.NewCount,
+	}
+	if search.CountedAt.Valid {
+		saved.CountedAt = &search.CountedAt.Time
+	}
+	return saved
+}
+
+func (s *SnippetsHandler) CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name  string `json:"name"`
+		Query string `json:"query"`
+	}
+
+	user, err := s.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	params := parameters{}
+	err = utilites.DecodeJsonBody(w, r, &params)
+	if err != nil {
+		return
+	}
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" || len(params.Name) > maxSavedSearchName {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, fmt.Sprintf("name must be between 1 and %d characters", maxSavedSearchName))
+		return
+	}
+	query, err := parseSavedQuery(params.Query)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := s.parseListing(r.Context(), query, user); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	search, err := s.DbQueries.CreateSavedSearch(r.Context(), database.CreateSavedSearchParams{UserID: user.ID, Name: params.Name, Query: query.Encode()})
+	if err != nil {
+		if isUniqueViolation(err) {
+			utilites.ResponseWithError(w, r, http.StatusConflict, "you already have a saved search with that name")
+			return
+		}
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "error saving search")
+		return
+	}
+	utilites.ResponseWithJson(w, r, http.StatusCreated, newSavedSearch(search))
+}
+
+// GetSavedSearches lists the caller's saved searches by name.
+func (s *SnippetsHandler) GetSavedSearches(w http.ResponseWriter, r *http.Request) {
+	user, err := s.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+	searches, err := s.DbQueries.GetSavedSearches(r.Context(), user.ID)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "error fetching saved searches")
+		return
+	}
+	saved := []SavedSearch{}
+	for _, search := range searches {
+		saved = append(saved, newSavedSearch(search))
+	}
+	utilites.ResponseWithJson(w, r, http.StatusOK, saved)
+}
+
+func (s *SnippetsHandler) GetSavedSearch(w http.ResponseWriter, r *http.Request) {
+	search, _, err := s.getOwnedSavedSearch(w, r)
+	if err != nil {
+		return
+	}
+	utilites.ResponseWithJson(w, r, http.StatusOK, newSavedSearch(search))
+}
+
+func (s *SnippetsHandler) DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	search, user, err := s.getOwnedSavedSearch(w, r)
+	if err != nil {
+		return
+	}
+	s.DbQueries.DeleteSavedSearch(r.Context(), database.DeleteSavedSearchParams{ID: search.ID, UserID: user.ID})
+	utilites.ResponseWithJson(w, r, http.StatusNoContent, "")
+}
+
+// GetSavedSearchNew lists the snippets matching a saved search that were
+// created since the caller last checked it, and marks it checked. The first
+// page records when the check happened in a since parameter, so the links to
+// later pages keep listing the same snippets.
+func (s *SnippetsHandler) GetSavedSearchNew(w http.ResponseWriter, r *http.Request) {
+	search, user, err := s.getOwnedSavedSearch(w, r)
+	if err != nil {
+		return
+	}
+
+	since := search.LastCheckedAt
+	requestQuery := r.URL.Query()
+	if sinceString := requestQuery.Get("since"); sinceString != "" {
+		since, err = time.Parse(time.RFC3339Nano, sinceString)
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, "since must be a time like 2025-01-31T15:04:05Z")
+			return
+		}
+	}
+
+	query, err := url.ParseQuery(search.Query)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	for _, param := range pagingParams {
+		if requestQuery.Has(param) {
+			query[param] = requestQuery[param]
+		}
+	}
+	l, err := s.parseListing(r.Context(), query, user)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	l.params.CreatedAfter = narrowAfter(l.params.CreatedAfter, since)
+
+	if !requestQuery.Has("since") {
+		if err := s.DbQueries.MarkSavedSearchChecked(r.Context(), search.ID); err != nil {
+			utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+			return
+		}
+		requestQuery.Set("since", since.UTC().Format(time.RFC3339Nano))
+		r.URL.RawQuery = requestQuery.Encode()
+	}
+	s.writeListing(w, r, l)
+}
+
+// RecordSavedSearchCounts counts, for every saved search, the snippets
+// created since its owner last checked it. Searches that can no longer be
+// run, such as ones naming a removed language, are skipped and reported.
+func (s *SnippetsHandler) RecordSavedSearchCounts(ctx context.Context) error {
+	var errs []error
+	after := uuid.Nil
+	for {
+		searches, err := s.DbQueries.GetSavedSearchesAfter(ctx, database.GetSavedSearchesAfterParams{ID: after, Limit: savedSearchBatch})
+		if err != nil {
+			return err
+		}
+		for _, search := range searches {
+			if err := s.recordSavedSearchCount(ctx, search); err != nil {
+				errs = append(errs, fmt.Errorf("saved search %s: %w", search.ID, err))
+			}
+		}
+		if len(searches) < savedSearchBatch {
+			return errors.Join(errs...)
+		}
+		after = searches[len(searches)-1].ID
+	}
+}
+
+func (s *SnippetsHandler) recordSavedSearchCount(ctx context.Context, search database.SavedSearch) error {
+	query, err := url.ParseQuery(search.Query)
+	if err != nil {
+		return err
+	}
+	l, err := s.parseListing(ctx, query, &auth.User{ID: search.UserID})
+	if err != nil {
+		return err
+	}
+	l.params.CreatedAfter = narrowAfter(l.params.CreatedAfter, search.LastCheckedAt)
+	l.withFacets = false
+	_, count, err := s.countListing(ctx, l)
+	if err != nil {
+		return err
+	}
+	// Matching on last_checked_at leaves the count at zero if the owner
+	// checked the search while it was being counted
+	return s.DbQueries.SetSavedSearchNewCount(ctx, database.SetSavedSearchNewCountParams{ID: search.ID, NewCount: count, LastCheckedAt: search.LastCheckedAt})
+}
+
+// getOwnedSavedSearch fetches the saved search named in the path for the
+// caller, writing the error response itself when it cannot. Other users'
+// saved searches are reported as not found.
+func (s *SnippetsHandler) getOwnedSavedSearch(w http.ResponseWriter, r *http.Request) (database.SavedSearch, *auth.User, error) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return database.SavedSearch{}, nil, err
+	}
+	user, err := s.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return database.SavedSearch{}, nil, err
+	}
+	search, err := s.DbQueries.GetSavedSearch(r.Context(), database.GetSavedSearchParams{ID: id, UserID: user.ID})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, errSavedSearchNotFound.Error())
+		return database.SavedSearch{}, nil, errSavedSearchNotFound
+	}
+	return search, user, nil
+}
+
+// parseSavedQuery accepts a GET /api/snippets query string, with or without
+// the URL before it, and drops its paging parameters.
+func parseSavedQuery(raw string) (url.Values, error) {
+	raw = strings.TrimSpace(raw)
+	if i := strings.Index(raw, "?"); i >= 0 {
+		raw = raw[i+1:]
+	}
+	query, err := url.ParseQuery(raw)
+	if err != nil {
+		return nil, errors.New("query must be a query string such as q=lang:sql+tag:postgres")
+	}
+	for _, param := range pagingParams {
+		query.Del(param)
+	}
+	if len(query) == 0 {
+		return nil, errors.New("query must not be empty")
+	}
+	return query, nil
+}
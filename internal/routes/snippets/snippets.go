@@ -2,52 +2,89 @@ package snippets
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/TKyleB/snippetz/internal/auth"
 	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/TKyleB/snippetz/internal/detect"
+	searchquery "github.com/TKyleB/snippetz/internal/search"
 	"github.com/TKyleB/snippetz/internal/utilites"
 	"github.com/google/uuid"
 )
 
 type SnippetsHandler struct {
+	DB          *sql.DB
 	DbQueries   *database.Queries
 	AuthService *auth.AuthService
+	Completions *searchquery.CompletionIndex
+	Detector    *detect.Detector
+
+	languages languageCache
+	views     viewCounter
+	training  sync.Mutex
 }
 type Snippet struct {
-	ID           uuid.UUID `json:"id"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Language     string    `json:"language"`
-	UserID       uuid.UUID `json:"author_id"`
-	UserName     string    `json:"username"`
-	SnippetText  string    `json:"snippet_text"`
-	SnippetDesc  string    `json:"snippet_desc"`
-	SnippetTitle string    `json:"snippet_title"`
+	ID           uuid.UUID               `json:"id"`
+	CreatedAt    time.Time               `json:"created_at"`
+	UpdatedAt    time.Time               `json:"updated_at"`
+	Language     string                  `json:"language"`
+	UserID       uuid.UUID               `json:"author_id"`
+	UserName     string                  `json:"username"`
+	SnippetText  string                  `json:"snippet_text"`
+	SnippetDesc  string                  `json:"snippet_desc"`
+	SnippetTitle string                  `json:"snippet_title"`
+	Visibility   string                  `json:"visibility"`
+	ForkedFromID *uuid.UUID              `json:"forked_from_id"`
+	ForkCount    int64                   `json:"fork_count"`
+	StarCount    int32                   `json:"star_count"`
+	ViewCount    int32                   `json:"view_count"`
+	StarredByMe  bool                    `json:"starred_by_me"`
+	ForkChain    []ForkParent            `json:"fork_chain,omitempty"`
+	Files        []SnippetFile           `json:"files,omitempty"`
+	Tags         []string                `json:"tags"`
+	Revision     int32                   `json:"revision,omitempty"`
+	Highlights   *Highlights             `json:"highlights,omitempty"`
+	Score        float32                 `json:"score,omitempty"`
+	Matches      []searchquery.LineMatch `json:"matches,omitempty"`
+
+	LanguageDetection *LanguageDetection `json:"language_detection,omitempty"`
+}
+
+// Highlights are HTML-escaped excerpts of a search result with the matching
+// words wrapped in <mark> tags.
+type Highlights struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Code        string `json:"code"`
 }
 
 type Results struct {
 	Snippets  []Snippet      `json:"snippets"`
 	Languages map[string]int `json:"languages"`
+	Facets    *Facets        `json:"facets,omitempty"`
 }
 
 type SnippetsResponse struct {
-	Count    int32   `json:"count"`
-	Next     *string `json:"next"`
-	Previous *string `json:"previous"`
-	Results  Results `json:"results"`
+	Count      *int32  `json:"count,omitempty"`
+	Next       *string `json:"next"`
+	Previous   *string `json:"previous"`
+	Suggestion *string `json:"suggestion,omitempty"`
+	Results    Results `json:"results"`
 }
 
 func (s *SnippetsHandler) CreateSnippet(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Language     string `json:"language"`
-		SnippetText  string `json:"snippet_text"`
-		SnippetDesc  string `json:"Snippet_desc"`
-		SnippetTitle string `json:"snippet_title"`
+		Language     string        `json:"language"`
+		SnippetText  string        `json:"snippet_text"`
+		SnippetDesc  string        `json:"Snippet_desc"`
+		SnippetTitle string        `json:"snippet_title"`
+		Visibility   string        `json:"visibility"`
+		Files        []SnippetFile `json:"files"`
+		Tags         []string      `json:"tags"`
 	}
 
 	user, err := s.AuthService.GetAuthenticatedUser(r)
@@ -61,10 +98,45 @@ func (s *SnippetsHandler) CreateSnippet(w http.ResponseWriter, r *http.Request)
 	if err != nil {
 		return
 	}
+	// A snippet is either a single snippet_text or a list of files whose first
+	// entry doubles as the snippet's language and text
+	if len(params.Files) > 0 {
+		if len(params.SnippetText) != 0 || params.Language != "" {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, "provide either snippet_text and language or files")
+			return
+		}
+		if err := validateFiles(params.Files); err != nil {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	// Files and snippets without a language, or with language auto, are
+	// detected. The snippet reports the detection of its first file.
+	var detection *LanguageDetection
+	languageDetected := make([]bool, len(params.Files))
+	for i, file := range params.Files {
+		if isAutoLanguage(file.Language) {
+			detected := s.detectLanguage(file.Name, file.Text)
+			params.Files[i].Language = detected.Language
+			languageDetected[i] = true
+			if i == 0 {
+				detection = &detected
+			}
+		}
+	}
+	if len(params.Files) > 0 {
+		params.Language = params.Files[0].Language
+		params.SnippetText = params.Files[0].Text
+	}
 	if len(params.SnippetText) == 0 {
 		utilites.ResponseWithError(w, r, http.StatusBadRequest, "snippet_text is empty")
 		return
 	}
+	if len(params.Files) == 0 && isAutoLanguage(params.Language) {
+		detected := s.detectLanguage("", params.SnippetText)
+		params.Language = detected.Language
+		detection = &detected
+	}
 	if len(params.SnippetDesc) == 0 {
 		utilites.ResponseWithError(w, r, http.StatusBadRequest, "snippet_desc is empty")
 		return
@@ -73,24 +145,89 @@ func (s *SnippetsHandler) CreateSnippet(w http.ResponseWriter, r *http.Request)
 		utilites.ResponseWithError(w, r, http.StatusBadRequest, "snippet_title is empty")
 		return
 	}
-	languageID, err := s.DbQueries.GetLanguageByName(r.Context(), params.Language)
+	if params.Visibility == "" {
+		params.Visibility = VisibilityPublic
+	}
+	if !IsValidVisibility(params.Visibility) {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "visibility must be one of public, unlisted or private")
+		return
+	}
+	tags, err := normalizeTags(params.Tags)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	language, err := s.DbQueries.GetLanguageByName(r.Context(), params.Language)
 	if err != nil {
 		errorText := fmt.Sprintf("language: %s is not currently supported", params.Language)
 		utilites.ResponseWithError(w, r, http.StatusBadRequest, errorText)
 		return
 	}
-	snippet, err := s.DbQueries.CreateSnippet(r.Context(), database.CreateSnippetParams{
-		LanguageID:         languageID,
+	// Aliases such as js are stored under the name of the language they stand for
+	params.Language = language.Name
+	if len(params.Files) == 0 {
+		params.Files = []SnippetFile{{Name: defaultFileName(fileExtension(language)), Language: params.Language, Text: params.SnippetText}}
+		languageDetected = []bool{detection != nil}
+	}
+	files, err := s.resolveFiles(r.Context(), params.Files)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	for i := range files {
+		files[i].LanguageDetected = languageDetected[i]
+		params.Files[i] = files[i].SnippetFile
+	}
+
+	tx, err := s.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	defer tx.Rollback()
+	qtx := s.DbQueries.WithTx(tx)
+
+	snippet, err := qtx.CreateSnippet(r.Context(), database.CreateSnippetParams{
+		LanguageID:         language.ID,
 		UserID:             user.ID,
 		SnippetText:        params.SnippetText,
 		SnippetDescription: params.SnippetDesc,
 		SnippetTitle:       params.SnippetTitle,
+		Visibility:         params.Visibility,
 	})
 	if err != nil {
 		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
 		return
 	}
-	utilites.ResponseWithJson(w, r, http.StatusCreated, Snippet{
+	if err := writeSnippetFiles(r.Context(), qtx, snippet.ID, files); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	if err := writeSnippetTags(r.Context(), qtx, snippet.ID, tags); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	// The search vector is computed on insert, before the files existed, so
+	// it is computed again from them
+	if err := qtx.SetSnippetSearchVector(r.Context(), snippet.ID); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	if err := indexSnippetCode(r.Context(), qtx, snippet.ID); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	if err := qtx.CreateSnippetRevision(r.Context(), snippet.ID); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+
+	w.Header().Set("ETag", snippetETag(snippet.UpdatedAt))
+	saved := Snippet{
 		ID:           snippet.ID,
 		CreatedAt:    snippet.CreatedAt,
 		UpdatedAt:    snippet.UpdatedAt,
@@ -99,98 +236,52 @@ func (s *SnippetsHandler) CreateSnippet(w http.ResponseWriter, r *http.Request)
 		SnippetText:  snippet.SnippetText,
 		SnippetDesc:  snippet.SnippetDescription,
 		SnippetTitle: snippet.SnippetTitle,
+		Visibility:   snippet.Visibility,
 		UserName:     snippet.Username,
-	})
+		Files:        params.Files,
+		Tags:         tags,
+	}
+	saved.LanguageDetection = detection
+	s.snippetSaved(saved)
+	utilites.ResponseWithJson(w, r, http.StatusCreated, saved)
 
 }
 func (s *SnippetsHandler) GetSnippets(w http.ResponseWriter, r *http.Request) {
-	// Set-up for pagination
-	var count int32
-	limit := int32(5)
-	offset := int32(0)
-
-	var language sql.NullString
-	var username sql.NullString
-	var search sql.NullString
-	languageString := r.URL.Query().Get("language")
-	usernameString := r.URL.Query().Get("username")
-	searchString := r.URL.Query().Get("q")
-	limitString := r.URL.Query().Get("limit")
-	offsetString := r.URL.Query().Get("offset")
-
-	if limitString != "" {
-		if parseLimit, err := strconv.ParseInt(limitString, 10, 32); err == nil {
-			limit = int32(parseLimit)
-		}
-	}
-	if offsetString != "" {
-		if parseOffset, err := strconv.ParseInt(offsetString, 10, 32); err == nil {
-			offset = int32(parseOffset)
-		}
-	}
-	if languageString != "" {
-		language.Scan(languageString)
-	}
-	if usernameString != "" {
-		username.Scan(usernameString)
-	}
-	if searchString != "" {
-		words := strings.Join(strings.Split(searchString, " "), " & ")
-		search.Scan(words)
+	viewer, authErr := s.AuthService.GetAuthenticatedUser(r)
+	l, err := s.parseListing(r.Context(), r.URL.Query(), viewer)
+	if errors.Is(err, errListingNeedsAuth) {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, authErr.Error())
+		return
 	}
-
-	dbSnippets, _ := s.DbQueries.GetSnippetsByCreatedAt(r.Context(), database.GetSnippetsByCreatedAtParams{Limit: limit, Offset: offset, Language: language, Username: username, Search: search})
-
-	languageCounts := make(map[string]int)
-	var snippets []Snippet
-	for i, snippet := range dbSnippets {
-		// If snippets. Update total count
-		if i == 0 {
-			count = int32(snippet.TotalCount)
-		}
-
-		languageCounts[snippet.Language] += 1
-
-		snippets = append(snippets, Snippet{
-			ID:           snippet.ID,
-			CreatedAt:    snippet.CreatedAt,
-			UpdatedAt:    snippet.UpdatedAt,
-			Language:     snippet.Language,
-			UserID:       snippet.UserID,
-			SnippetText:  snippet.SnippetText,
-			UserName:     snippet.Username,
-			SnippetDesc:  snippet.SnippetDescription,
-			SnippetTitle: snippet.SnippetTitle,
-		})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+		return
 	}
+	s.writeListing(w, r, l)
+}
 
-	baseURL := fmt.Sprintf("%s://%s", r.URL.Scheme, r.Host)
-	var next *string
-	var previous *string
-
-	if !(offset+limit >= count) {
-		nextURL := fmt.Sprintf("%s/api/snippets?limit=%v&offset=%v", baseURL, limit, offset+limit)
-		next = &nextURL
-	}
-	if offset > 0 {
-		prevURL := fmt.Sprintf("%s/api/snippets?limit=%v&offset=%v", baseURL, limit, max(offset-limit, 0))
-		previous = &prevURL
-	}
-	var results = Results{}
-	results.Snippets = snippets
-	results.Languages = languageCounts
-	response := SnippetsResponse{Count: count, Next: next, Previous: previous, Results: results}
+func (s *SnippetsHandler) GetSnippetById(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+	s.getSnippet(w, r, prefersPlainText(r))
+}
 
-	utilites.ResponseWithJson(w, r, http.StatusOK, &response)
+func (s *SnippetsHandler) GetSnippetRaw(w http.ResponseWriter, r *http.Request) {
+	s.getSnippet(w, r, true)
 }
 
-func (s *SnippetsHandler) GetSnippetById(w http.ResponseWriter, r *http.Request) {
+// getSnippet writes a snippet, or one of its revisions, as JSON or as its raw code.
+func (s *SnippetsHandler) getSnippet(w http.ResponseWriter, r *http.Request, raw bool) {
 	idString := r.PathValue("id")
 	id, err := uuid.Parse(idString)
 	if err != nil {
 		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	dbSnippet, err := s.getVisibleSnippet(r, id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, err.Error())
+		return
 	}
-	dbSnippet, _ := s.DbQueries.GetSnippetById(r.Context(), id)
 	snippet := Snippet{
 		ID:           dbSnippet.ID,
 		CreatedAt:    dbSnippet.CreatedAt,
@@ -201,6 +292,72 @@ func (s *SnippetsHandler) GetSnippetById(w http.ResponseWriter, r *http.Request)
 		SnippetTitle: dbSnippet.SnippetTitle,
 		SnippetText:  dbSnippet.SnippetText,
 		Language:     dbSnippet.Language,
+		Visibility:   dbSnippet.Visibility,
+		ForkedFromID: nullUUIDPtr(dbSnippet.ForkedFromID),
+		ForkCount:    dbSnippet.ForkCount,
+		StarCount:    dbSnippet.StarCount,
+		ViewCount:    dbSnippet.ViewCount,
+		Tags:         dbSnippet.Tags,
+	}
+	if viewer := s.viewer(r); viewer != nil {
+		snippet.StarredByMe, err = s.DbQueries.IsSnippetStarred(r.Context(), database.IsSnippetStarredParams{UserID: viewer.ID, SnippetID: id})
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+			return
+		}
+	}
+	if dbSnippet.ForkedFromID.Valid {
+		snippet.ForkChain, err = s.getForkChain(r, id)
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+			return
+		}
+	}
+
+	// Serve an older revision's content when one is requested
+	if revString := r.URL.Query().Get("rev"); revString != "" {
+		rev, err := parseRevision(revString)
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		revision, err := s.DbQueries.GetSnippetRevision(r.Context(), database.GetSnippetRevisionParams{SnippetID: id, Revision: rev})
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusNotFound, "revision not found")
+			return
+		}
+		snippet.UpdatedAt = revision.CreatedAt
+		snippet.SnippetDesc = revision.SnippetDescription
+		snippet.SnippetTitle = revision.SnippetTitle
+		snippet.SnippetText = revision.SnippetText
+		snippet.Language = revision.Language
+		snippet.Revision = revision.Revision
+		snippet.Files, err = revisionFiles(revision.Files)
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+			return
+		}
+		if raw {
+			writeRaw(w, rawFileName(snippet.SnippetTitle, s.languageExtension(r.Context(), snippet.Language)), snippet.SnippetText)
+			return
+		}
+		utilites.ResponseWithJson(w, r, http.StatusOK, &snippet)
+		return
+	}
+
+	snippet.Files, err = getSnippetFiles(r.Context(), s.DbQueries, id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+
+	// Views are buffered and written in batches by FlushViewCounts
+	s.views.add(id)
+
+	w.Header().Set("ETag", snippetETag(dbSnippet.UpdatedAt))
+	if raw {
+		writeRaw(w, rawFileName(snippet.SnippetTitle, s.languageExtension(r.Context(), snippet.Language)), snippet.SnippetText)
+		return
 	}
 	utilites.ResponseWithJson(w, r, http.StatusOK, &snippet)
 }
@@ -227,6 +384,237 @@ func (s *SnippetsHandler) DeleteSnippetById(w http.ResponseWriter, r *http.Reque
 		return
 	}
 	s.DbQueries.DeleteSnippetById(r.Context(), snippet.ID)
+	s.snippetDeleted(snippet.ID)
 	utilites.ResponseWithJson(w, r, http.StatusNoContent, "")
 
 }
+
+func (s *SnippetsHandler) UpdateSnippet(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Language     *string       `json:"language"`
+		SnippetText  *string       `json:"snippet_text"`
+		SnippetDesc  *string       `json:"snippet_desc"`
+		SnippetTitle *string       `json:"snippet_title"`
+		Visibility   *string       `json:"visibility"`
+		Files        []SnippetFile `json:"files"`
+		Tags         []string      `json:"tags"`
+	}
+
+	idString := r.PathValue("id")
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	user, err := s.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		utilites.ResponseWithError(w, r, http.StatusPreconditionRequired, "If-Match header is required")
+		return
+	}
+
+	snippet, err := s.DbQueries.GetSnippetById(r.Context(), id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, "snippet not found")
+		return
+	}
+	if snippet.UserID != user.ID {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, "")
+		return
+	}
+
+	// Only allow the write if the client has seen the latest version of the snippet
+	expectedUpdatedAt := snippet.UpdatedAt
+	if ifMatch != "*" {
+		expectedUpdatedAt, err = parseETag(ifMatch)
+		if err != nil || !expectedUpdatedAt.Equal(snippet.UpdatedAt) {
+			w.Header().Set("ETag", snippetETag(snippet.UpdatedAt))
+			utilites.ResponseWithError(w, r, http.StatusPreconditionFailed, "snippet has been modified since it was fetched")
+			return
+		}
+	}
+
+	params := parameters{}
+	err = utilites.DecodeJsonBody(w, r, &params)
+	if err != nil {
+		return
+	}
+
+	updateParams := database.UpdateSnippetParams{ID: id, UpdatedAt: expectedUpdatedAt}
+	var files []resolvedFile
+	var tags []string
+	if params.Tags != nil {
+		tags, err = normalizeTags(params.Tags)
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if params.Files != nil {
+		if params.SnippetText != nil || params.Language != nil {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, "provide either snippet_text and language or files")
+			return
+		}
+		if len(params.Files) == 0 {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, "files is empty")
+			return
+		}
+		if err := validateFiles(params.Files); err != nil {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		files, err = s.resolveFiles(r.Context(), params.Files)
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		updateParams.SnippetText.Scan(files[0].Text)
+		updateParams.LanguageID = uuid.NullUUID{UUID: files[0].LanguageID, Valid: true}
+	}
+	if params.SnippetText != nil {
+		if len(*params.SnippetText) == 0 {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, "snippet_text is empty")
+			return
+		}
+		updateParams.SnippetText.Scan(*params.SnippetText)
+	}
+	if params.SnippetDesc != nil {
+		if len(*params.SnippetDesc) == 0 {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, "snippet_desc is empty")
+			return
+		}
+		updateParams.SnippetDescription.Scan(*params.SnippetDesc)
+	}
+	if params.SnippetTitle != nil {
+		if len(*params.SnippetTitle) == 0 {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, "snippet_title is empty")
+			return
+		}
+		updateParams.SnippetTitle.Scan(*params.SnippetTitle)
+	}
+	if params.Visibility != nil {
+		if !IsValidVisibility(*params.Visibility) {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, "visibility must be one of public, unlisted or private")
+			return
+		}
+		updateParams.Visibility.Scan(*params.Visibility)
+	}
+	if params.Language != nil {
+		language, err := s.DbQueries.GetLanguageByName(r.Context(), *params.Language)
+		if err != nil {
+			errorText := fmt.Sprintf("language: %s is not currently supported", *params.Language)
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, errorText)
+			return
+		}
+		updateParams.LanguageID = uuid.NullUUID{UUID: language.ID, Valid: true}
+	}
+
+	tx, err := s.DB.BeginTx(r.Context(), nil)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	defer tx.Rollback()
+	qtx := s.DbQueries.WithTx(tx)
+
+	// The files before the edit are needed to move line comment anchors
+	previousFiles, err := getSnippetFiles(r.Context(), qtx, id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+
+	// Files are written before the snippet row so its search vector trigger sees them
+	if files != nil {
+		err = qtx.DeleteSnippetFiles(r.Context(), id)
+		if err == nil {
+			err = writeSnippetFiles(r.Context(), qtx, id, files)
+		}
+	} else if updateParams.SnippetText.Valid || updateParams.LanguageID.Valid {
+		err = qtx.UpdatePrimarySnippetFile(r.Context(), database.UpdatePrimarySnippetFileParams{
+			FileText:   updateParams.SnippetText,
+			LanguageID: updateParams.LanguageID,
+			SnippetID:  id,
+		})
+	}
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+
+	// The WHERE clause re-checks updated_at so a write that lands between the
+	// read above and this update is still rejected
+	updated, err := qtx.UpdateSnippet(r.Context(), updateParams)
+	if errors.Is(err, sql.ErrNoRows) {
+		utilites.ResponseWithError(w, r, http.StatusPreconditionFailed, "snippet has been modified since it was fetched")
+		return
+	}
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	if err := indexSnippetCode(r.Context(), qtx, id); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	if err := qtx.CreateSnippetRevision(r.Context(), id); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	if params.Tags != nil {
+		if err := writeSnippetTags(r.Context(), qtx, id, tags); err != nil {
+			utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+			return
+		}
+	}
+	updatedFiles, err := getSnippetFiles(r.Context(), qtx, id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	if err := remapLineComments(r.Context(), qtx, id, previousFiles, updatedFiles); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	updatedTags, err := qtx.GetSnippetTags(r.Context(), id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	starred, err := qtx.IsSnippetStarred(r.Context(), database.IsSnippetStarredParams{UserID: user.ID, SnippetID: id})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+
+	w.Header().Set("ETag", snippetETag(updated.UpdatedAt))
+	saved := Snippet{
+		ID:           updated.ID,
+		CreatedAt:    updated.CreatedAt,
+		UpdatedAt:    updated.UpdatedAt,
+		Language:     updated.Language,
+		UserID:       updated.UserID,
+		SnippetText:  updated.SnippetText,
+		SnippetDesc:  updated.SnippetDescription,
+		SnippetTitle: updated.SnippetTitle,
+		Visibility:   updated.Visibility,
+		ForkedFromID: nullUUIDPtr(updated.ForkedFromID),
+		ForkCount:    int64(updated.ForkCount),
+		StarCount:    updated.StarCount,
+		ViewCount:    updated.ViewCount,
+		StarredByMe:  starred,
+		UserName:     updated.Username,
+		Files:        updatedFiles,
+		Tags:         updatedTags,
+	}
+	s.snippetSaved(saved)
+	utilites.ResponseWithJson(w, r, http.StatusOK, saved)
+}
@@ -0,0 +1,148 @@
+package snippets
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/TKyleB/snippetz/internal/utilites"
+	"github.com/google/uuid"
+)
+
+type StarStatus struct {
+	StarCount   int32 `json:"star_count"`
+	StarredByMe bool  `json:"starred_by_me"`
+}
+
+func (s *SnippetsHandler) StarSnippet(w http.ResponseWriter, r *http.Request) {
+	s.setStar(w, r, true)
+}
+
+func (s *SnippetsHandler) UnstarSnippet(w http.ResponseWriter, r *http.Request) {
+	s.setStar(w, r, false)
+}
+
+// setStar adds or removes the caller's star. Both directions are idempotent
+// and respond with the snippet's resulting star state.
+func (s *SnippetsHandler) setStar(w http.ResponseWriter, r *http.Request, starred bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	user, err := s.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if _, err := s.getVisibleSnippet(r, id); err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if starred {
+		err = s.DbQueries.StarSnippet(r.Context(), database.StarSnippetParams{UserID: user.ID, SnippetID: id})
+	} else {
+		err = s.DbQueries.UnstarSnippet(r.Context(), database.UnstarSnippetParams{UserID: user.ID, SnippetID: id})
+	}
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	count, err := s.DbQueries.GetSnippetStarCount(r.Context(), id)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	utilites.ResponseWithJson(w, r, http.StatusOK, StarStatus{StarCount: count, StarredByMe: starred})
+}
+
+// GetUserStars lists the snippets a user has starred, most recently starred
+// first. Only public snippets and the viewer's own snippets are included.
+func (s *SnippetsHandler) GetUserStars(w http.ResponseWriter, r *http.Request) {
+	var count int32
+	limit := int32(5)
+	offset := int32(0)
+	limitString := r.URL.Query().Get("limit")
+	offsetString := r.URL.Query().Get("offset")
+
+	if limitString != "" {
+		parseLimit, err := ParseIntParam("limit", limitString, 1, MaxLimit)
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		limit = parseLimit
+	}
+	if offsetString != "" {
+		parseOffset, err := ParseIntParam("offset", offsetString, 0, math.MaxInt32)
+		if err != nil {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		offset = parseOffset
+	}
+
+	username := r.PathValue("username")
+	user, err := s.DbQueries.GetUserByUsername(r.Context(), username)
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusNotFound, "user not found")
+		return
+	}
+	var viewerID uuid.NullUUID
+	if viewer := s.viewer(r); viewer != nil {
+		viewerID = uuid.NullUUID{UUID: viewer.ID, Valid: true}
+	}
+
+	dbSnippets, err := s.DbQueries.GetStarredSnippets(r.Context(), database.GetStarredSnippetsParams{Limit: limit, Offset: offset, ViewerID: viewerID, UserID: user.ID})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+
+	languageCounts := make(map[string]int)
+	var snippets []Snippet
+	for i, snippet := range dbSnippets {
+		if i == 0 {
+			count = int32(snippet.TotalCount)
+		}
+
+		languageCounts[snippet.Language] += 1
+
+		snippets = append(snippets, Snippet{
+			ID:           snippet.ID,
+			CreatedAt:    snippet.CreatedAt,
+			UpdatedAt:    snippet.UpdatedAt,
+			Language:     snippet.Language,
+			UserID:       snippet.UserID,
+			SnippetText:  snippet.SnippetText,
+			UserName:     snippet.Username,
+			SnippetDesc:  snippet.SnippetDescription,
+			SnippetTitle: snippet.SnippetTitle,
+			Visibility:   snippet.Visibility,
+			ForkedFromID: nullUUIDPtr(snippet.ForkedFromID),
+			ForkCount:    snippet.ForkCount,
+			StarCount:    snippet.StarCount,
+			ViewCount:    snippet.ViewCount,
+			StarredByMe:  snippet.StarredByMe,
+			Tags:         snippet.Tags,
+		})
+	}
+
+	baseURL := fmt.Sprintf("%s://%s/api/users/%s/stars", r.URL.Scheme, r.Host, user.Username)
+	var next *string
+	var previous *string
+
+	if !(offset+limit >= count) {
+		nextURL := fmt.Sprintf("%s?limit=%v&offset=%v", baseURL, limit, offset+limit)
+		next = &nextURL
+	}
+	if offset > 0 {
+		prevURL := fmt.Sprintf("%s?limit=%v&offset=%v", baseURL, limit, max(offset-limit, 0))
+		previous = &prevURL
+	}
+	response := SnippetsResponse{Count: &count, Next: next, Previous: previous, Results: Results{Snippets: snippets, Languages: languageCounts}}
+
+	utilites.ResponseWithJson(w, r, http.StatusOK, &response)
+}
@@ -0,0 +1,44 @@
+package snippets
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// suggestQuery offers a corrected search when a full-text search finds
+// nothing, swapping each plain word for the closest word indexed from public
+// snippets. Operators, phrases, qualifiers and exclusions are left alone. It
+// returns nil when no word could be improved.
+func (s *SnippetsHandler) suggestQuery(ctx context.Context, input string) *string {
+	words := strings.Fields(input)
+	changed := false
+	for i, word := range words {
+		if !isPlainWord(word) {
+			continue
+		}
+		closest, err := s.DbQueries.GetClosestLexeme(ctx, strings.ToLower(word))
+		if err != nil || closest == strings.ToLower(word) {
+			continue
+		}
+		words[i] = closest
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	suggestion := strings.Join(words, " ")
+	return &suggestion
+}
+
+func isPlainWord(word string) bool {
+	if word == "OR" {
+		return false
+	}
+	for _, r := range word {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
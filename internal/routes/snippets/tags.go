@@ -0,0 +1,52 @@
+package snippets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxSnippetTags = 10
+
+// normalizeTags lowercases and de-duplicates tags, rejecting any that are
+// empty, too long or contain characters other than letters, digits and - _ . + #
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || len(tag) > 32 {
+			return nil, errors.New("tags must be between 1 and 32 characters")
+		}
+		for _, char := range tag {
+			if !(char >= 'a' && char <= 'z') && !(char >= '0' && char <= '9') && !strings.ContainsRune("-_.+#", char) {
+				return nil, fmt.Errorf("tag: %s contains invalid characters", tag)
+			}
+		}
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > maxSnippetTags {
+		return nil, fmt.Errorf("a snippet can have at most %d tags", maxSnippetTags)
+	}
+	return normalized, nil
+}
+
+// writeSnippetTags replaces a snippet's tags, creating any tags that don't exist yet.
+func writeSnippetTags(ctx context.Context, qtx *database.Queries, snippetID uuid.UUID, tags []string) error {
+	if err := qtx.DeleteSnippetTags(ctx, snippetID); err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	if err := qtx.CreateTags(ctx, tags); err != nil {
+		return err
+	}
+	return qtx.AddSnippetTags(ctx, database.AddSnippetTagsParams{SnippetID: snippetID, Names: tags})
+}
@@ -0,0 +1,65 @@
+package snippets
+
+import (
+	"context"
+	"sync"
+
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/google/uuid"
+)
+
+// viewCounter buffers snippet views so reading a snippet doesn't write to
+// its row. FlushViewCounts adds them to the snippets in one update.
+type viewCounter struct {
+	mu     sync.Mutex
+	counts map[uuid.UUID]int32
+}
+
+func (c *viewCounter) add(id uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[uuid.UUID]int32)
+	}
+	c.counts[id]++
+}
+
+// take empties the buffer and returns what was in it.
+func (c *viewCounter) take() map[uuid.UUID]int32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := c.counts
+	c.counts = nil
+	return counts
+}
+
+// restore puts back counts that could not be flushed.
+func (c *viewCounter) restore(counts map[uuid.UUID]int32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[uuid.UUID]int32)
+	}
+	for id, n := range counts {
+		c.counts[id] += n
+	}
+}
+
+// FlushViewCounts writes the views counted since the last flush. It is run
+// periodically in the background; views of deleted snippets are dropped.
+func (s *SnippetsHandler) FlushViewCounts(ctx context.Context) error {
+	counts := s.views.take()
+	if len(counts) == 0 {
+		return nil
+	}
+	params := database.AddSnippetViewCountsParams{}
+	for id, n := range counts {
+		params.SnippetIds = append(params.SnippetIds, id.String())
+		params.Views = append(params.Views, n)
+	}
+	if err := s.DbQueries.AddSnippetViewCounts(ctx, params); err != nil {
+		s.views.restore(counts)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package snippets
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/TKyleB/snippetz/internal/auth"
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	VisibilityPublic   = "public"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPrivate  = "private"
+)
+
+var errSnippetNotFound = errors.New("snippet not found")
+
+func IsValidVisibility(visibility string) bool {
+	switch visibility {
+	case VisibilityPublic, VisibilityUnlisted, VisibilityPrivate:
+		return true
+	}
+	return false
+}
+
+// viewer returns the user making the request or nil for anonymous requests.
+// An invalid token is treated the same as no token.
+func (s *SnippetsHandler) viewer(r *http.Request) *auth.User {
+	user, err := s.AuthService.GetAuthenticatedUser(r)
+	if err != nil {
+		return nil
+	}
+	return user
+}
+
+// CanView reports whether viewer may read a snippet. Public and unlisted
+// snippets are readable by anyone holding the ID, private ones only by their owner.
+func CanView(visibility string, ownerID uuid.UUID, viewer *auth.User) bool {
+	if visibility != VisibilityPrivate {
+		return true
+	}
+	return viewer != nil && viewer.ID == ownerID
+}
+
+// getVisibleSnippet fetches a snippet, hiding private snippets from everyone
+// but their owner by reporting them as not found.
+func (s *SnippetsHandler) getVisibleSnippet(r *http.Request, id uuid.UUID) (database.GetSnippetByIdRow, error) {
+	snippet, err := s.DbQueries.GetSnippetById(r.Context(), id)
+	if err != nil {
+		return database.GetSnippetByIdRow{}, errSnippetNotFound
+	}
+	if !CanView(snippet.Visibility, snippet.UserID, s.viewer(r)) {
+		return database.GetSnippetByIdRow{}, errSnippetNotFound
+	}
+	return snippet, nil
+}
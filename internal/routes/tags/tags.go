@@ -0,0 +1,53 @@
+package tags
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/TKyleB/snippetz/internal/utilites"
+)
+
+type TagsHandler struct {
+	DbQueries *database.Queries
+}
+
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// GetTags returns tags ordered by how many public snippets use them.
+func (t *TagsHandler) GetTags(w http.ResponseWriter, r *http.Request) {
+	limit := int32(100)
+	var prefix sql.NullString
+	limitString := r.URL.Query().Get("limit")
+	prefixString := r.URL.Query().Get("prefix")
+
+	if limitString != "" {
+		parseLimit, err := strconv.ParseInt(limitString, 10, 32)
+		if err != nil || parseLimit < 1 || parseLimit > 1000 {
+			utilites.ResponseWithError(w, r, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+		limit = int32(parseLimit)
+	}
+	if prefixString != "" {
+		// Escape LIKE wildcards so the prefix is matched literally
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.ToLower(prefixString))
+		prefix.Scan(escaped)
+	}
+
+	dbTags, err := t.DbQueries.GetTagCounts(r.Context(), database.GetTagCountsParams{Prefix: prefix, Limit: limit})
+	if err != nil {
+		utilites.ResponseWithError(w, r, http.StatusInternalServerError, "server error")
+		return
+	}
+	tags := []TagCount{}
+	for _, tag := range dbTags {
+		tags = append(tags, TagCount{Name: tag.Name, Count: tag.Count})
+	}
+	utilites.ResponseWithJson(w, r, http.StatusOK, &tags)
+}
@@ -44,7 +44,7 @@ func (u *UsersHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	user, err := u.DbQueries.CreateUser(r.Context(), database.CreateUserParams{Username: req.Username, HashedPassword: hashedPassword})
-	userResponse := auth.User{ID: user.ID, CreatedAt: user.CreatedAt, UpdatedAt: user.UpdatedAt, Username: user.Username}
+	userResponse := auth.User{ID: user.ID, CreatedAt: user.CreatedAt, UpdatedAt: user.UpdatedAt, Username: user.Username, IsAdmin: user.IsAdmin}
 	if err != nil {
 		// If error is non-unique username
 		var pqErr *pq.Error
@@ -169,5 +169,5 @@ func (u *UsersHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 		utilites.ResponseWithError(w, r, http.StatusUnauthorized, err.Error())
 		return
 	}
-	utilites.ResponseWithJson(w, r, http.StatusOK, auth.User{ID: user.ID, CreatedAt: user.CreatedAt, UpdatedAt: user.UpdatedAt, Username: user.Username})
+	utilites.ResponseWithJson(w, r, http.StatusOK, auth.User{ID: user.ID, CreatedAt: user.CreatedAt, UpdatedAt: user.UpdatedAt, Username: user.Username, IsAdmin: user.IsAdmin})
 }
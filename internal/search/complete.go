@@ -0,0 +1,291 @@
+package search
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of Completion.
+const (
+	CompletionTitle    = "title"
+	CompletionTag      = "tag"
+	CompletionUser     = "user"
+	CompletionLanguage = "language"
+)
+
+// Completion is a suggested search term. Count is the number of public
+// snippets it appears on.
+type Completion struct {
+	Kind  string `json:"kind"`
+	Text  string `json:"text"`
+	Count int    `json:"count"`
+}
+
+// CompletionDocument is the text of one public snippet that completions are
+// drawn from.
+type CompletionDocument struct {
+	ID       uuid.UUID
+	Title    string
+	Username string
+	Language string
+	Tags     []string
+}
+
+// CompletionIndex completes search prefixes from snippet titles, tags,
+// usernames and languages. It keeps every lowercased term, and every word
+// a title contains onwards, in one sorted slice, so a prefix is a binary
+// search and a scan of the keys sharing it.
+// Documents are added and removed one at a time as snippets change, and
+// the index is safe for concurrent use.
+type CompletionIndex struct {
+	mu    sync.RWMutex
+	docs  map[uuid.UUID]CompletionDocument
+	terms map[termID]*completionTerm
+	keys  []completionKey
+}
+
+type termID struct {
+	kind string
+	text string
+}
+
+type completionTerm struct {
+	termID
+	count int
+}
+
+// completionKey points at a term from the lowercased text it is found by.
+// Start keys begin at the start of the term rather than at a later word.
+type completionKey struct {
+	key   string
+	term  *completionTerm
+	start bool
+}
+
+func NewCompletionIndex() *CompletionIndex {
+	return &CompletionIndex{
+		docs:  map[uuid.UUID]CompletionDocument{},
+		terms: map[termID]*completionTerm{},
+	}
+}
+
+// Replace rebuilds the index from docs. The keys of every term are collected
+// and sorted once, rather than inserted in order one at a time.
+func (i *CompletionIndex) Replace(docs []CompletionDocument) {
+	rebuilt := NewCompletionIndex()
+	for _, doc := range docs {
+		rebuilt.docs[doc.ID] = doc
+		for _, id := range documentTerms(doc) {
+			term := rebuilt.terms[id]
+			if term == nil {
+				term = &completionTerm{termID: id}
+				rebuilt.terms[id] = term
+				rebuilt.keys = append(rebuilt.keys, termKeys(term)...)
+			}
+			term.count++
+		}
+	}
+	slices.SortFunc(rebuilt.keys, compareKeys)
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.docs, i.terms, i.keys = rebuilt.docs, rebuilt.terms, rebuilt.keys
+}
+
+// Add indexes doc, replacing any earlier version of it.
+func (i *CompletionIndex) Add(doc CompletionDocument) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.remove(doc.ID)
+	i.add(doc)
+}
+
+// Remove drops the document with the given id, if it is indexed.
+func (i *CompletionIndex) Remove(id uuid.UUID) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.remove(id)
+}
+
+// RenameLanguage moves the documents in language from to language to. Only
+// the language terms change, so a rename doesn't rebuild the index.
+func (i *CompletionIndex) RenameLanguage(from, to string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	old := i.terms[termID{CompletionLanguage, from}]
+	if old == nil || from == to {
+		return
+	}
+	for id, doc := range i.docs {
+		if doc.Language == from {
+			doc.Language = to
+			i.docs[id] = doc
+		}
+	}
+	delete(i.terms, old.termID)
+	i.deleteKeys(old)
+	term := i.terms[termID{CompletionLanguage, to}]
+	if term == nil {
+		term = &completionTerm{termID: termID{CompletionLanguage, to}}
+		i.terms[term.termID] = term
+		i.insertKeys(term)
+	}
+	term.count += old.count
+}
+
+// Complete returns up to limit terms starting with prefix, ignoring case.
+// Terms that start with the prefix rank above titles with a later word
+// starting with it, then terms on more snippets rank first.
+func (i *CompletionIndex) Complete(prefix string, limit int) []Completion {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" || limit <= 0 {
+		return nil
+	}
+
+	i.mu.RLock()
+	matched := map[*completionTerm]bool{}
+	first, _ := slices.BinarySearchFunc(i.keys, prefix, func(k completionKey, prefix string) int {
+		return strings.Compare(k.key, prefix)
+	})
+	for _, k := range i.keys[first:] {
+		if !strings.HasPrefix(k.key, prefix) {
+			break
+		}
+		matched[k.term] = matched[k.term] || k.start
+	}
+	// Only the best limit matches are kept, in order, so a short prefix
+	// matching most of the index isn't sorted in full
+	best := make([]rankedCompletion, 0, min(limit, len(matched)))
+	for term, start := range matched {
+		result := rankedCompletion{Completion{Kind: term.kind, Text: term.text, Count: term.count}, start}
+		if len(best) == limit && compareRanked(result, best[len(best)-1]) >= 0 {
+			continue
+		}
+		at, _ := slices.BinarySearchFunc(best, result, compareRanked)
+		if len(best) == limit {
+			best = best[:len(best)-1]
+		}
+		best = slices.Insert(best, at, result)
+	}
+	i.mu.RUnlock()
+
+	completions := make([]Completion, 0, len(best))
+	for _, result := range best {
+		completions = append(completions, result.Completion)
+	}
+	return completions
+}
+
+type rankedCompletion struct {
+	Completion
+	start bool
+}
+
+func compareRanked(a, b rankedCompletion) int {
+	if a.start != b.start {
+		if a.start {
+			return -1
+		}
+		return 1
+	}
+	return cmp.Or(
+		cmp.Compare(b.Count, a.Count),
+		cmp.Compare(len(a.Text), len(b.Text)),
+		strings.Compare(a.Text, b.Text),
+		strings.Compare(a.Kind, b.Kind),
+	)
+}
+
+func (i *CompletionIndex) add(doc CompletionDocument) {
+	i.docs[doc.ID] = doc
+	for _, id := range documentTerms(doc) {
+		term := i.terms[id]
+		if term == nil {
+			term = &completionTerm{termID: id}
+			i.terms[id] = term
+			i.insertKeys(term)
+		}
+		term.count++
+	}
+}
+
+func (i *CompletionIndex) remove(id uuid.UUID) {
+	doc, ok := i.docs[id]
+	if !ok {
+		return
+	}
+	delete(i.docs, id)
+	for _, id := range documentTerms(doc) {
+		term := i.terms[id]
+		if term == nil {
+			continue
+		}
+		term.count--
+		if term.count > 0 {
+			continue
+		}
+		delete(i.terms, id)
+		i.deleteKeys(term)
+	}
+}
+
+func (i *CompletionIndex) insertKeys(term *completionTerm) {
+	for _, k := range termKeys(term) {
+		at, _ := slices.BinarySearchFunc(i.keys, k, compareKeys)
+		i.keys = slices.Insert(i.keys, at, k)
+	}
+}
+
+func (i *CompletionIndex) deleteKeys(term *completionTerm) {
+	for _, k := range termKeys(term) {
+		if at, found := slices.BinarySearchFunc(i.keys, k, compareKeys); found {
+			i.keys = slices.Delete(i.keys, at, at+1)
+		}
+	}
+}
+
+func documentTerms(doc CompletionDocument) []termID {
+	terms := []termID{
+		{CompletionTitle, doc.Title},
+		{CompletionUser, doc.Username},
+		{CompletionLanguage, doc.Language},
+	}
+	for _, tag := range doc.Tags {
+		terms = append(terms, termID{CompletionTag, tag})
+	}
+	return terms
+}
+
+// termKeys returns the keys a term is found by. Titles are also found by
+// each later word in them, so "Upsert in postgres" completes "post".
+func termKeys(term *completionTerm) []completionKey {
+	text := strings.ToLower(term.text)
+	keys := []completionKey{{key: text, term: term, start: true}}
+	if term.kind != CompletionTitle {
+		return keys
+	}
+	previous := ' '
+	for at, r := range text {
+		if at > 0 && isWordRune(r) && !isWordRune(previous) {
+			keys = append(keys, completionKey{key: text[at:], term: term})
+		}
+		previous = r
+	}
+	return keys
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func compareKeys(a, b completionKey) int {
+	return cmp.Or(
+		strings.Compare(a.key, b.key),
+		strings.Compare(a.term.kind, b.term.kind),
+		strings.Compare(a.term.text, b.term.text),
+	)
+}
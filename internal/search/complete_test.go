@@ -0,0 +1,193 @@
+package search
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func completionDocs() []CompletionDocument {
+	return []CompletionDocument{
+		{ID: uuid.New(), Title: "Upsert in postgres", Username: "alice", Language: "SQL", Tags: []string{"postgres", "database"}},
+		{ID: uuid.New(), Title: "Post a form", Username: "bob", Language: "Go", Tags: []string{"http"}},
+		{ID: uuid.New(), Title: "Postgres connection pool", Username: "alice", Language: "Go", Tags: []string{"postgres"}},
+	}
+}
+
+func TestComplete(t *testing.T) {
+	index := NewCompletionIndex()
+	index.Replace(completionDocs())
+
+	tests := []struct {
+		name   string
+		prefix string
+		limit  int
+		want   []Completion
+	}{
+		{
+			name:   "empty prefix",
+			prefix: "  ",
+			limit:  10,
+			want:   nil,
+		},
+		{
+			name:   "no limit",
+			prefix: "post",
+			limit:  0,
+			want:   nil,
+		},
+		{
+			name:   "no match",
+			prefix: "rust",
+			limit:  10,
+			want:   []Completion{},
+		},
+		{
+			name:   "starts rank above later words, then by count",
+			prefix: "POST",
+			limit:  10,
+			want: []Completion{
+				{Kind: CompletionTag, Text: "postgres", Count: 2},
+				{Kind: CompletionTitle, Text: "Post a form", Count: 1},
+				{Kind: CompletionTitle, Text: "Postgres connection pool", Count: 1},
+				{Kind: CompletionTitle, Text: "Upsert in postgres", Count: 1},
+			},
+		},
+		{
+			name:   "limit keeps the best",
+			prefix: "post",
+			limit:  2,
+			want: []Completion{
+				{Kind: CompletionTag, Text: "postgres", Count: 2},
+				{Kind: CompletionTitle, Text: "Post a form", Count: 1},
+			},
+		},
+		{
+			name:   "usernames and languages",
+			prefix: "go",
+			limit:  10,
+			want:   []Completion{{Kind: CompletionLanguage, Text: "Go", Count: 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := index.Complete(tt.prefix, tt.limit)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Complete(%q, %d) = %v, want %v", tt.prefix, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompletionAddRemove(t *testing.T) {
+	docs := completionDocs()
+	tests := []struct {
+		name   string
+		change func(*CompletionIndex)
+		want   []CompletionDocument
+	}{
+		{
+			name:   "add one at a time",
+			change: func(i *CompletionIndex) {},
+			want:   docs,
+		},
+		{
+			name:   "remove",
+			change: func(i *CompletionIndex) { i.Remove(docs[2].ID) },
+			want:   docs[:2],
+		},
+		{
+			name:   "remove unknown",
+			change: func(i *CompletionIndex) { i.Remove(uuid.New()) },
+			want:   docs,
+		},
+		{
+			name: "add replaces",
+			change: func(i *CompletionIndex) {
+				changed := docs[1]
+				changed.Title = "Send a form"
+				changed.Tags = nil
+				i.Add(changed)
+			},
+			want: []CompletionDocument{docs[0], {ID: docs[1].ID, Title: "Send a form", Username: "bob", Language: "Go"}, docs[2]},
+		},
+		{
+			name:   "rename language",
+			change: func(i *CompletionIndex) { i.RenameLanguage("Go", "Golang") },
+			want:   withLanguage(docs, "Go", "Golang"),
+		},
+		{
+			name:   "rename into another language",
+			change: func(i *CompletionIndex) { i.RenameLanguage("Go", "SQL") },
+			want:   withLanguage(docs, "Go", "SQL"),
+		},
+		{
+			name:   "rename unknown language",
+			change: func(i *CompletionIndex) { i.RenameLanguage("Rust", "Go") },
+			want:   docs,
+		},
+		{
+			name: "remove then add back",
+			change: func(i *CompletionIndex) {
+				for _, doc := range docs {
+					i.Remove(doc.ID)
+				}
+				for _, doc := range docs {
+					i.Add(doc)
+				}
+			},
+			want: docs,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := NewCompletionIndex()
+			for _, doc := range docs {
+				index.Add(doc)
+			}
+			tt.change(index)
+
+			// Changing the index one document at a time must leave it as
+			// rebuilding it from the same documents would
+			want := NewCompletionIndex()
+			want.Replace(tt.want)
+			for _, prefix := range []string{"p", "post", "s", "a", "go", "f", "http", "d", "r"} {
+				got, wantCompletions := index.Complete(prefix, 20), want.Complete(prefix, 20)
+				if !slices.Equal(got, wantCompletions) {
+					t.Errorf("Complete(%q) = %v, want %v", prefix, got, wantCompletions)
+				}
+			}
+			if len(index.keys) != len(want.keys) {
+				t.Errorf("index has %d keys, want %d", len(index.keys), len(want.keys))
+			}
+		})
+	}
+}
+
+func withLanguage(docs []CompletionDocument, from, to string) []CompletionDocument {
+	renamed := slices.Clone(docs)
+	for n := range renamed {
+		if renamed[n].Language == from {
+			renamed[n].Language = to
+		}
+	}
+	return renamed
+}
+
+func TestCompleteLimitMatchesFullSort(t *testing.T) {
+	var docs []CompletionDocument
+	for n := range 200 {
+		docs = append(docs, CompletionDocument{ID: uuid.New(), Title: fmt.Sprintf("term %d", n%37), Username: fmt.Sprintf("t%d", n%11), Language: "Go"})
+	}
+	index := NewCompletionIndex()
+	index.Replace(docs)
+
+	all := index.Complete("t", 1000)
+	for _, limit := range []int{1, 5, 20} {
+		if got := index.Complete("t", limit); !slices.Equal(got, all[:limit]) {
+			t.Errorf("Complete(%q, %d) = %v, want %v", "t", limit, got, all[:limit])
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Query is a parsed search. Text is a tsquery literal and is empty when the
+// search has no text terms.
+// The other fields are filters taken from lang:, user:, tag: and created:
+// qualifiers.
+type Query struct {
+	Text          string
+	Languages     []string
+	Usernames     []string
+	Tags          []string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// SyntaxError reports a malformed search. Pos is the one-based character
+// position the problem was found at.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid query at position %d: %s", e.Pos, e.Msg)
+}
+
+// Text qualifiers restrict a term to one part of the snippet through the
+// weights the search vector is built with.
+var textQualifiers = map[string]string{
+	"title": "A",
+	"desc":  "B",
+	"code":  "CD",
+}
+
+var filterQualifiers = map[string]bool{
+	"lang":    true,
+	"user":    true,
+	"tag":     true,
+	"created": true,
+}
+
+const dateLayout = "2006-01-02"
+
+// Parse parses a search query. Terms separated by spaces must all match,
+// OR matches either side, a leading - excludes a term, "quoted phrases"
+// match words in order, a trailing * matches prefixes and parentheses group.
+// title:, desc: and code: limit a term to that part of the snippet, and
+// lang:, user:, tag: and created:>2025-01-01 filter the results.
+func Parse(input string) (*Query, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, query: &Query{}}
+	if p.peek().kind == tokenEOF {
+		return p.query, nil
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, &SyntaxError{Pos: tok.pos, Msg: "unexpected )"}
+	}
+	p.query.Text = root.tsquery()
+	return p.query, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenPhrase
+	tokenOr
+	tokenNot
+	tokenLParen
+	tokenRParen
+	tokenQualifier
+)
+
+type token struct {
+	kind     tokenKind
+	pos      int
+	text     string
+	field    string
+	phrase   bool
+	valuePos int
+}
+
+// lex splits a query into tokens. Parentheses only group at the start of a
+// term and only close an open group, so code such as print(x) stays one word.
+func lex(input string) ([]token, error) {
+	runes := []rune(input)
+	var tokens []token
+	depth := 0
+	i := 0
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"':
+			text, next, err := lexPhrase(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenPhrase, pos: i + 1, text: text})
+			i = next
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, pos: i + 1})
+			depth++
+			i++
+		case r == ')' && depth > 0:
+			tokens = append(tokens, token{kind: tokenRParen, pos: i + 1})
+			depth--
+			i++
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]):
+			tokens = append(tokens, token{kind: tokenNot, pos: i + 1})
+			i++
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '"' && !(runes[i] == ')' && depth > 0) {
+				i++
+			}
+			word := string(runes[start:i])
+			if word == "OR" {
+				tokens = append(tokens, token{kind: tokenOr, pos: start + 1})
+				continue
+			}
+			field, value, found := strings.Cut(word, ":")
+			field = strings.ToLower(field)
+			if _, ok := textQualifiers[field]; found && (ok || filterQualifiers[field]) {
+				tok := token{kind: tokenQualifier, pos: start + 1, field: field, text: value, valuePos: start + len([]rune(field)) + 2}
+				if value == "" && i < len(runes) && runes[i] == '"' {
+					text, next, err := lexPhrase(runes, i)
+					if err != nil {
+						return nil, err
+					}
+					tok.text = text
+					tok.phrase = true
+					i = next
+				}
+				if strings.TrimSpace(tok.text) == "" {
+					return nil, &SyntaxError{Pos: tok.valuePos, Msg: fmt.Sprintf("missing value after %s:", field)}
+				}
+				tokens = append(tokens, tok)
+				continue
+			}
+			tokens = append(tokens, token{kind: tokenWord, pos: start + 1, text: word})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes) + 1}), nil
+}
+
+// lexPhrase reads the quoted phrase starting at runes[start] and returns its
+// text and the index just past the closing quote.
+func lexPhrase(runes []rune, start int) (string, int, error) {
+	end := start + 1
+	for end < len(runes) && runes[end] != '"' {
+		end++
+	}
+	if end == len(runes) {
+		return "", 0, &SyntaxError{Pos: start + 1, Msg: "unterminated quoted phrase"}
+	}
+	text := string(runes[start+1 : end])
+	if strings.TrimSpace(text) == "" {
+		return "", 0, &SyntaxError{Pos: start + 1, Msg: "empty quoted phrase"}
+	}
+	return text, end + 1, nil
+}
+
+type parser struct {
+	tokens  []token
+	pos     int
+	depth   int
+	negated int
+	filters []token
+	query   *Query
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (node, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	branches := []node{first}
+	for p.peek().kind == tokenOr {
+		or := p.next()
+		if kind := p.peek().kind; kind == tokenEOF || kind == tokenRParen || kind == tokenOr {
+			return nil, &SyntaxError{Pos: or.pos, Msg: "OR must be followed by a term"}
+		}
+		branch, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, branch)
+	}
+	if len(branches) == 1 {
+		return first, nil
+	}
+	// Filters apply to every result, so they cannot be one side of an OR
+	if p.depth == 0 && len(p.filters) > 0 {
+		filter := p.filters[0]
+		return nil, &SyntaxError{Pos: filter.pos, Msg: fmt.Sprintf("%s: cannot be combined with OR", filter.field)}
+	}
+	return orNode(branches), nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	var terms []node
+	for {
+		switch tok := p.peek(); tok.kind {
+		case tokenEOF, tokenRParen, tokenOr:
+			if len(terms) == 0 {
+				if tok.kind == tokenOr {
+					return nil, &SyntaxError{Pos: tok.pos, Msg: "OR must follow a term"}
+				}
+				return nil, &SyntaxError{Pos: tok.pos, Msg: "expected a term"}
+			}
+			return andNode(terms), nil
+		}
+		term, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().kind != tokenNot {
+		return p.parsePrimary()
+	}
+	not := p.next()
+	if kind := p.peek().kind; kind == tokenEOF || kind == tokenRParen || kind == tokenOr {
+		return nil, &SyntaxError{Pos: not.pos, Msg: "- must be followed by a term"}
+	}
+	p.negated++
+	child, err := p.parseUnary()
+	p.negated--
+	if err != nil {
+		return nil, err
+	}
+	return notNode{child}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenWord:
+		return newTerm(tok.text, false, ""), nil
+	case tokenPhrase:
+		return newTerm(tok.text, true, ""), nil
+	case tokenLParen:
+		if p.peek().kind == tokenRParen {
+			return nil, &SyntaxError{Pos: tok.pos, Msg: "empty parentheses"}
+		}
+		p.depth++
+		group, err := p.parseOr()
+		p.depth--
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokenRParen {
+			return nil, &SyntaxError{Pos: tok.pos, Msg: "missing closing )"}
+		}
+		return group, nil
+	case tokenQualifier:
+		if weight, ok := textQualifiers[tok.field]; ok {
+			return newTerm(tok.text, tok.phrase, weight), nil
+		}
+		if err := p.addFilter(tok); err != nil {
+			return nil, err
+		}
+		return andNode(nil), nil
+	}
+	return nil, &SyntaxError{Pos: tok.pos, Msg: "expected a term"}
+}
+
+func (p *parser) addFilter(tok token) error {
+	if p.depth > 0 || p.negated > 0 {
+		return &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("%s: cannot be negated or grouped", tok.field)}
+	}
+	p.filters = append(p.filters, tok)
+	value := strings.TrimSpace(tok.text)
+	switch tok.field {
+	case "lang":
+		p.query.Languages = append(p.query.Languages, strings.ToLower(value))
+	case "user":
+		p.query.Usernames = append(p.query.Usernames, value)
+	case "tag":
+		p.query.Tags = append(p.query.Tags, strings.ToLower(value))
+	case "created":
+		return p.addCreated(tok.valuePos, value)
+	}
+	return nil
+}
+
+// addCreated narrows the creation date range. Dates cover whole days, so
+// created:>2025-01-01 starts at 2025-01-02 and created:2025-01-01 is that day only.
+func (p *parser) addCreated(pos int, value string) error {
+	op := ""
+	for _, candidate := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, candidate) {
+			op = candidate
+			break
+		}
+	}
+	day, err := time.Parse(dateLayout, strings.TrimPrefix(value, op))
+	if err != nil {
+		return &SyntaxError{Pos: pos + len(op), Msg: "created: expects a date such as 2025-01-01"}
+	}
+	nextDay := day.AddDate(0, 0, 1)
+
+	var after, before *time.Time
+	switch op {
+	case ">":
+		after = &nextDay
+	case ">=":
+		after = &day
+	case "<":
+		before = &day
+	case "<=":
+		before = &nextDay
+	default:
+		after, before = &day, &nextDay
+	}
+	if after != nil && (p.query.CreatedAfter == nil || after.After(*p.query.CreatedAfter)) {
+		p.query.CreatedAfter = after
+	}
+	if before != nil && (p.query.CreatedBefore == nil || before.Before(*p.query.CreatedBefore)) {
+		p.query.CreatedBefore = before
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package search
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	day := func(s string) *time.Time {
+		d, _ := time.Parse(dateLayout, s)
+		return &d
+	}
+	tests := []struct {
+		name  string
+		input string
+		want  Query
+	}{
+		{name: "empty", input: "", want: Query{}},
+		{name: "spaces", input: "   ", want: Query{}},
+		{name: "word", input: "hello", want: Query{Text: "'hello'"}},
+		{name: "and", input: "foo bar", want: Query{Text: "('foo' & 'bar')"}},
+		{name: "or", input: "foo OR bar", want: Query{Text: "('foo' | 'bar')"}},
+		{name: "lowercase or is a word", input: "foo or bar", want: Query{Text: "('foo' & 'or' & 'bar')"}},
+		{name: "not", input: "-foo", want: Query{Text: "!('foo')"}},
+		{name: "phrase", input: `"hello world"`, want: Query{Text: "('hello' <-> 'world')"}},
+		{name: "prefix", input: "pre*", want: Query{Text: "'pre':*"}},
+		{name: "group", input: "(foo OR bar) baz", want: Query{Text: "(('foo' | 'bar') & 'baz')"}},
+		{name: "call is one word", input: "print(x)", want: Query{Text: "('print' <-> 'x')"}},
+		{name: "operator", input: ":=", want: Query{Text: "':='"}},
+		{name: "quote splits words", input: "it's", want: Query{Text: "('it' <-> 's')"}},
+		{name: "backslash splits words", input: `back\slash`, want: Query{Text: "('back' <-> 'slash')"}},
+		{name: "dotted identifier", input: "json.Marshal", want: Query{Text: "('json.marshal' | 'json' <-> 'marshal')"}},
+		{name: "title", input: "title:foo", want: Query{Text: "'foo':A"}},
+		{name: "code phrase", input: `code:"a b"`, want: Query{Text: "('a':CD <-> 'b':CD)"}},
+		{
+			name:  "filters",
+			input: "lang:Go user:alice tag:Web created:>2025-01-01 q",
+			want:  Query{Text: "'q'", Languages: []string{"go"}, Usernames: []string{"alice"}, Tags: []string{"web"}, CreatedAfter: day("2025-01-02")},
+		},
+		{name: "filters only", input: "lang:go lang:sql", want: Query{Languages: []string{"go", "sql"}}},
+		{name: "created on a day", input: "created:2025-01-01", want: Query{CreatedAfter: day("2025-01-01"), CreatedBefore: day("2025-01-02")}},
+		{name: "created up to a day", input: "created:<=2025-01-01", want: Query{CreatedBefore: day("2025-01-02")}},
+		{name: "created ranges narrow", input: "created:>=2025-01-01 created:>=2025-03-01", want: Query{CreatedAfter: day("2025-03-01")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned error %v", tt.input, err)
+			}
+			if got.Text != tt.want.Text ||
+				!slices.Equal(got.Languages, tt.want.Languages) ||
+				!slices.Equal(got.Usernames, tt.want.Usernames) ||
+				!slices.Equal(got.Tags, tt.want.Tags) ||
+				!equalTime(got.CreatedAfter, tt.want.CreatedAfter) ||
+				!equalTime(got.CreatedBefore, tt.want.CreatedBefore) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.input, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		pos   int
+	}{
+		{name: "unterminated phrase", input: `"open`, pos: 1},
+		{name: "empty phrase", input: `foo "  "`, pos: 5},
+		{name: "trailing or", input: "foo OR", pos: 5},
+		{name: "leading or", input: "OR foo", pos: 1},
+		{name: "not before closing", input: "(foo -)", pos: 6},
+		{name: "empty parentheses", input: "()", pos: 1},
+		{name: "missing closing", input: "(foo", pos: 1},
+		{name: "missing value", input: "lang:", pos: 6},
+		{name: "filter in or", input: "lang:go OR foo", pos: 1},
+		{name: "grouped filter", input: "(lang:go)", pos: 2},
+		{name: "negated filter", input: "-lang:go", pos: 2},
+		{name: "bad date", input: "created:>bad", pos: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse(%q) error = %v, want a SyntaxError", tt.input, err)
+			}
+			if syntaxErr.Pos != tt.pos {
+				t.Errorf("Parse(%q) error at %d, want %d: %v", tt.input, syntaxErr.Pos, tt.pos, err)
+			}
+		})
+	}
+}
+
+func TestQuoteLexeme(t *testing.T) {
+	tests := []struct {
+		text   string
+		suffix string
+		want   string
+	}{
+		{text: "foo", want: "'foo'"},
+		{text: "it's", want: "'it''s'"},
+		{text: `a\b`, want: `'a\\b'`},
+		{text: `'\`, suffix: "*A", want: `'''\\':*A`},
+	}
+	for _, tt := range tests {
+		if got := quoteLexeme(tt.text, tt.suffix); got != tt.want {
+			t.Errorf("quoteLexeme(%q, %q) = %s, want %s", tt.text, tt.suffix, got, tt.want)
+		}
+	}
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,160 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxRegexLength  = 256
+	maxRegexNodes   = 500
+	maxLineMatches  = 20
+	minLiteralRunes = 3
+)
+
+// Regex is a pattern that matches snippet code line by line, the way grep
+// does.
+// Literal is the longest string every match must contain. It is empty when
+// the pattern has no literal long enough to narrow candidates with a
+// trigram index.
+type Regex struct {
+	re      *regexp.Regexp
+	Literal string
+}
+
+// LineMatch is a line of code that matched a Regex, with the lines around it
+// and the name of the file it is in. Line is one-based.
+type LineMatch struct {
+	File   string   `json:"file"`
+	Line   int      `json:"line"`
+	Text   string   `json:"text"`
+	Before []string `json:"before"`
+	After  []string `json:"after"`
+}
+
+// CompileRegex compiles an RE2 pattern, rejecting patterns that are too long
+// or too complex to run against every snippet.
+func CompileRegex(pattern string) (*Regex, error) {
+	if utf8.RuneCountInString(pattern) > maxRegexLength {
+		return nil, fmt.Errorf("regex must be at most %d characters", maxRegexLength)
+	}
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %v", err)
+	}
+	simplified := parsed.Simplify()
+	if countNodes(simplified) > maxRegexNodes {
+		return nil, errors.New("regex is too complex")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %v", err)
+	}
+	literal := requiredLiteral(simplified)
+	if utf8.RuneCountInString(literal) < minLiteralRunes {
+		literal = ""
+	}
+	return &Regex{re: re, Literal: literal}, nil
+}
+
+// MatchLines returns the lines of a file's text the regex matches, each with
+// up to context lines before and after it. At most 20 lines are returned.
+func (r *Regex) MatchLines(file, text string, context int) []LineMatch {
+	lines := strings.Split(text, "\n")
+	var matches []LineMatch
+	for i, line := range lines {
+		if !r.re.MatchString(line) {
+			continue
+		}
+		matches = append(matches, LineMatch{
+			File:   file,
+			Line:   i + 1,
+			Text:   line,
+			Before: lines[max(i-context, 0):i],
+			After:  lines[i+1 : min(i+1+context, len(lines))],
+		})
+		if len(matches) == maxLineMatches {
+			break
+		}
+	}
+	return matches
+}
+
+// countNodes counts the nodes of a parsed pattern. Counted after
+// simplification, repetitions such as (a{50}){50} are expanded, so the count
+// tracks the size of the program the pattern compiles to.
+func countNodes(re *syntax.Regexp) int {
+	nodes := 1
+	for _, sub := range re.Sub {
+		nodes += countNodes(sub)
+	}
+	return nodes
+}
+
+// requiredLiteral returns the longest run of literal text that every match of
+// re contains.
+func requiredLiteral(re *syntax.Regexp) string {
+	if literal, ok := exactLiteral(re); ok {
+		return literal
+	}
+	switch re.Op {
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiteral(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min >= 1 {
+			return requiredLiteral(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		longest := ""
+		run := ""
+		for _, sub := range re.Sub {
+			if literal, ok := exactLiteral(sub); ok {
+				run += literal
+				continue
+			}
+			// abc+ still contains abc, but nothing can follow on from the run
+			if sub.Op == syntax.OpPlus {
+				if literal, ok := exactLiteral(sub.Sub[0]); ok {
+					run += literal
+				}
+			}
+			longest = longer(longest, run)
+			run = ""
+			longest = longer(longest, requiredLiteral(sub))
+		}
+		return longer(longest, run)
+	}
+	return ""
+}
+
+// exactLiteral reports whether re matches exactly one string, and returns it.
+func exactLiteral(re *syntax.Regexp) (string, bool) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return string(re.Rune), true
+	case syntax.OpCapture:
+		return exactLiteral(re.Sub[0])
+	case syntax.OpConcat:
+		var literal strings.Builder
+		for _, sub := range re.Sub {
+			s, ok := exactLiteral(sub)
+			if !ok {
+				return "", false
+			}
+			literal.WriteString(s)
+		}
+		return literal.String(), true
+	}
+	return "", false
+}
+
+func longer(a, b string) string {
+	if utf8.RuneCountInString(b) > utf8.RuneCountInString(a) {
+		return b
+	}
+	return a
+}
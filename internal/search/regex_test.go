@@ -0,0 +1,109 @@
+package search
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCompileRegex(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		literal string
+		err     string
+	}{
+		{name: "literal", pattern: "foo", literal: "foo"},
+		{name: "too short to narrow", pattern: "ab", literal: ""},
+		{name: "case insensitive", pattern: "(?i)HandleFunc", literal: "handlefunc"},
+		{name: "case insensitive escape", pattern: `(?i)json\.Marshal`, literal: "json.marshal"},
+		{name: "plus keeps its run", pattern: "abc+", literal: "abc"},
+		{name: "plus group", pattern: "(abc)+", literal: "abc"},
+		{name: "repeat", pattern: "(abc){2}", literal: "abcabc"},
+		{name: "optional repeat", pattern: "(abc){0,2}", literal: ""},
+		{name: "star before literal", pattern: "x*abcd", literal: "abcd"},
+		{name: "alternation", pattern: "foo|bar", literal: ""},
+		{name: "longest run", pattern: `func\s+mainLoop`, literal: "mainLoop"},
+		{name: "class before literal", pattern: "[a-z]+Error", literal: "Error"},
+		{name: "invalid", pattern: "(", err: "invalid regex"},
+		{name: "too long", pattern: strings.Repeat("a", maxRegexLength+1), err: "at most"},
+		{name: "too complex", pattern: "(a{30}){30}", err: "too complex"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re, err := CompileRegex(tt.pattern)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("CompileRegex(%q) error = %v, want one containing %q", tt.pattern, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CompileRegex(%q) returned error %v", tt.pattern, err)
+			}
+			// Literals are matched with ILIKE, so their case doesn't matter
+			if !strings.EqualFold(re.Literal, tt.literal) {
+				t.Errorf("CompileRegex(%q).Literal = %q, want %q", tt.pattern, re.Literal, tt.literal)
+			}
+		})
+	}
+}
+
+func TestMatchLines(t *testing.T) {
+	text := "package main\n\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n"
+	tests := []struct {
+		name    string
+		pattern string
+		context int
+		want    []LineMatch
+	}{
+		{name: "no match", pattern: "nothing", context: 1, want: nil},
+		{
+			name:    "context",
+			pattern: `func \w+`,
+			context: 1,
+			want:    []LineMatch{{File: "main.go", Line: 3, Text: "func main() {", Before: []string{""}, After: []string{"\tfmt.Println(\"hi\")"}}},
+		},
+		{
+			name:    "context stops at the edges",
+			pattern: "^package",
+			context: 2,
+			want:    []LineMatch{{File: "main.go", Line: 1, Text: "package main", Before: []string{}, After: []string{"", "func main() {"}}},
+		},
+		{
+			name:    "several lines",
+			pattern: "main",
+			context: 0,
+			want: []LineMatch{
+				{File: "main.go", Line: 1, Text: "package main", Before: []string{}, After: []string{}},
+				{File: "main.go", Line: 3, Text: "func main() {", Before: []string{}, After: []string{}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re, err := CompileRegex(tt.pattern)
+			if err != nil {
+				t.Fatalf("CompileRegex(%q) returned error %v", tt.pattern, err)
+			}
+			got := re.MatchLines("main.go", text, tt.context)
+			if !slices.EqualFunc(got, tt.want, equalLineMatch) {
+				t.Errorf("MatchLines(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchLinesLimit(t *testing.T) {
+	re, err := CompileRegex("x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := re.MatchLines("x.txt", strings.Repeat("x\n", 50), 0); len(got) != maxLineMatches {
+		t.Errorf("MatchLines returned %d lines, want %d", len(got), maxLineMatches)
+	}
+}
+
+func equalLineMatch(a, b LineMatch) bool {
+	return a.File == b.File && a.Line == b.Line && a.Text == b.Text && slices.Equal(a.Before, b.Before) && slices.Equal(a.After, b.After)
+}
@@ -0,0 +1,142 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// Postgres ignores positions above this and keeps at most 256 per lexeme
+	maxPosition          = 16383
+	maxPositionsPerToken = 256
+	maxLexemeLength      = 256
+)
+
+// Lexeme is one searchable word of source code. Whole identifiers, dotted
+// paths and operators are emitted as they appear; Part marks the pieces they
+// are split into.
+type Lexeme struct {
+	Text string
+	Part bool
+}
+
+// Tokenize splits code into lowercase lexemes the way a code search tool
+// would. parseJSONBody yields parsejsonbody followed by the parts parse,
+// json and body; http.HandleFunc yields http.handlefunc, http, handlefunc,
+// handle and func; operators such as := and => are kept whole.
+func Tokenize(code string) []Lexeme {
+	runes := []rune(code)
+	var lexemes []Lexeme
+	i := 0
+	for i < len(runes) {
+		switch r := runes[i]; {
+		case isIdentRune(r):
+			start := i
+			for i < len(runes) && (isIdentRune(runes[i]) || (runes[i] == '.' && i > start && i+1 < len(runes) && isIdentRune(runes[i+1]))) {
+				i++
+			}
+			lexemes = appendIdentifier(lexemes, string(runes[start:i]))
+		case isOperatorRune(r):
+			start := i
+			for i < len(runes) && isOperatorRune(runes[i]) {
+				i++
+			}
+			if i-start > 1 && i-start <= 3 {
+				lexemes = append(lexemes, Lexeme{Text: string(runes[start:i])})
+			}
+		default:
+			i++
+		}
+	}
+	return lexemes
+}
+
+func appendIdentifier(lexemes []Lexeme, ident string) []Lexeme {
+	if len(ident) > maxLexemeLength {
+		return lexemes
+	}
+	lexemes = append(lexemes, Lexeme{Text: strings.ToLower(ident)})
+	seen := map[string]bool{strings.ToLower(ident): true}
+	addPart := func(part string) {
+		part = strings.ToLower(part)
+		if part == "" || seen[part] {
+			return
+		}
+		seen[part] = true
+		lexemes = append(lexemes, Lexeme{Text: part, Part: true})
+	}
+	for _, segment := range strings.Split(ident, ".") {
+		addPart(segment)
+		for _, word := range strings.Split(segment, "_") {
+			addPart(word)
+			for _, piece := range splitCamel(word) {
+				addPart(piece)
+			}
+		}
+	}
+	return lexemes
+}
+
+// splitCamel splits camelCase and PascalCase words, keeping acronyms together:
+// parseJSONBody becomes parse, JSON, Body. Digits stay with the letters before them.
+func splitCamel(word string) []string {
+	runes := []rune(word)
+	var pieces []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		lowerToUpper := (unicode.IsLower(prev) || unicode.IsDigit(prev)) && unicode.IsUpper(cur)
+		acronymEnd := unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if lowerToUpper || acronymEnd {
+			pieces = append(pieces, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(pieces, string(runes[start:]))
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isOperatorRune(r rune) bool {
+	return strings.ContainsRune("+-*/%=!<>&|^~:?", r)
+}
+
+// CodeVector renders lexemes as a tsvector literal. Whole tokens get weight
+// C and their parts weight D, so exact identifier matches rank higher.
+func CodeVector(lexemes []Lexeme) string {
+	positions := make(map[string][]string)
+	for i, lexeme := range lexemes {
+		position := i + 1
+		if position > maxPosition {
+			break
+		}
+		if len(positions[lexeme.Text]) >= maxPositionsPerToken {
+			continue
+		}
+		weight := "C"
+		if lexeme.Part {
+			weight = "D"
+		}
+		positions[lexeme.Text] = append(positions[lexeme.Text], fmt.Sprintf("%d%s", position, weight))
+	}
+
+	texts := make([]string, 0, len(positions))
+	for text := range positions {
+		texts = append(texts, text)
+	}
+	sort.Strings(texts)
+
+	var sb strings.Builder
+	escaper := strings.NewReplacer(`\`, `\\`, "'", "''")
+	for i, text := range texts {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString("'" + escaper.Replace(text) + "':" + strings.Join(positions[text], ","))
+	}
+	return sb.String()
+}
@@ -0,0 +1,85 @@
+package search
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		// want lists whole lexemes as they are and parts prefixed with ~
+		want []string
+	}{
+		{name: "empty", code: "", want: nil},
+		{name: "camel case", code: "parseJSONBody", want: []string{"parsejsonbody", "~parse", "~json", "~body"}},
+		{name: "dotted path", code: "http.HandleFunc", want: []string{"http.handlefunc", "~http", "~handlefunc", "~handle", "~func"}},
+		{name: "snake case", code: "snake_case_name", want: []string{"snake_case_name", "~snake", "~case", "~name"}},
+		{name: "digits stay with letters", code: "v2Name", want: []string{"v2name", "~v2", "~name"}},
+		{name: "operators", code: "x := a => b", want: []string{"x", ":=", "a", "=>", "b"}},
+		{name: "single and long operators are dropped", code: "a ++ b !== c ==== d + e", want: []string{"a", "++", "b", "!==", "c", "d", "e"}},
+		{name: "quotes and backslashes split", code: `it's a\b`, want: []string{"it", "s", "a", "b"}},
+		{name: "trailing dot", code: "end.", want: []string{"end"}},
+		{name: "too long", code: strings.Repeat("x", maxLexemeLength+1) + " y", want: []string{"y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, lexeme := range Tokenize(tt.code) {
+				if lexeme.Part {
+					got = append(got, "~"+lexeme.Text)
+				} else {
+					got = append(got, lexeme.Text)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Tokenize(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeVector(t *testing.T) {
+	tests := []struct {
+		name    string
+		lexemes []Lexeme
+		want    string
+	}{
+		{name: "empty", lexemes: nil, want: ""},
+		{
+			name:    "weights and sorted lexemes",
+			lexemes: Tokenize("parseJSONBody"),
+			want:    "'body':4D 'json':3D 'parse':2D 'parsejsonbody':1C",
+		},
+		{
+			name:    "repeated lexemes",
+			lexemes: Tokenize("a b a"),
+			want:    "'a':1C,3C 'b':2C",
+		},
+		{
+			name:    "quotes and backslashes",
+			lexemes: []Lexeme{{Text: "it's"}, {Text: `a\b`, Part: true}},
+			want:    `'a\\b':2D 'it''s':1C`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeVector(tt.lexemes); got != tt.want {
+				t.Errorf("CodeVector(%v) = %s, want %s", tt.lexemes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeVectorLimits(t *testing.T) {
+	lexemes := make([]Lexeme, maxPosition+10)
+	for i := range lexemes {
+		lexemes[i] = Lexeme{Text: "x"}
+	}
+	got := CodeVector(lexemes)
+	if positions := strings.Count(got, ","); positions+1 != maxPositionsPerToken {
+		t.Errorf("CodeVector kept %d positions, want %d", positions+1, maxPositionsPerToken)
+	}
+}
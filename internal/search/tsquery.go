@@ -0,0 +1,136 @@
+package search
+
+import (
+	"strings"
+)
+
+// node is a parsed query expression that compiles to to_tsquery syntax.
+// Nodes that end up with no searchable words compile to an empty string and
+// are dropped by their parent.
+type node interface {
+	tsquery() string
+}
+
+type termNode struct {
+	words  []string
+	prefix bool
+	weight string
+}
+
+type notNode struct {
+	child node
+}
+
+type andNode []node
+
+type orNode []node
+
+// newTerm builds a term from a word or phrase. A trailing * on a single word
+// makes it a prefix match.
+func newTerm(text string, phrase bool, weight string) termNode {
+	if phrase {
+		return termNode{words: strings.Fields(text), weight: weight}
+	}
+	if len(text) > 1 && strings.HasSuffix(text, "*") {
+		return termNode{words: []string{strings.TrimSuffix(text, "*")}, prefix: true, weight: weight}
+	}
+	return termNode{words: []string{text}, weight: weight}
+}
+
+// tsquery renders the term as tsquery syntax. Words are normalised with the
+// same tokenizer used for the code index, so characters that mean something
+// to tsquery are never passed through. Phrase words must appear in order.
+func (t termNode) tsquery() string {
+	var words []string
+	for i, word := range t.words {
+		suffix := t.weight
+		if t.prefix && i == len(t.words)-1 {
+			suffix = "*" + suffix
+		}
+		if compiled := wordQuery(word, suffix); compiled != "" {
+			words = append(words, compiled)
+		}
+	}
+	if len(words) > 1 {
+		return "(" + strings.Join(words, " <-> ") + ")"
+	}
+	return strings.Join(words, "")
+}
+
+// wordQuery compiles one word of a search. Identifiers joined with dots or
+// underscores match either whole, as stored in the code index, or as their
+// separate parts, as the 'simple' parser stores them in the text index.
+// Operators are only searched for when the word is nothing but an operator.
+func wordQuery(word, suffix string) string {
+	var whole, operators []string
+	for _, lexeme := range Tokenize(word) {
+		if lexeme.Part {
+			continue
+		}
+		if strings.ContainsFunc(lexeme.Text, isIdentRune) {
+			whole = append(whole, lexeme.Text)
+		} else {
+			operators = append(operators, lexeme.Text)
+		}
+	}
+	if len(whole) == 0 {
+		whole = operators
+	}
+
+	var parts []string
+	for _, text := range whole {
+		segments := strings.FieldsFunc(text, func(r rune) bool { return r == '.' || r == '_' })
+		if len(segments) < 2 {
+			parts = append(parts, quoteLexeme(text, suffix))
+			continue
+		}
+		for i, segment := range segments {
+			segments[i] = quoteLexeme(segment, suffix)
+		}
+		parts = append(parts, "("+quoteLexeme(text, suffix)+" | "+strings.Join(segments, " <-> ")+")")
+	}
+	if len(parts) > 1 {
+		return "(" + strings.Join(parts, " <-> ") + ")"
+	}
+	return strings.Join(parts, "")
+}
+
+func quoteLexeme(text, suffix string) string {
+	lexeme := "'" + strings.NewReplacer(`\`, `\\`, "'", "''").Replace(text) + "'"
+	if suffix != "" {
+		lexeme += ":" + suffix
+	}
+	return lexeme
+}
+
+func (n notNode) tsquery() string {
+	child := n.child.tsquery()
+	if child == "" {
+		return ""
+	}
+	return "!(" + child + ")"
+}
+
+func (n andNode) tsquery() string {
+	return join(n, " & ")
+}
+
+func (n orNode) tsquery() string {
+	return join(n, " | ")
+}
+
+func join(nodes []node, op string) string {
+	var parts []string
+	for _, child := range nodes {
+		if part := child.tsquery(); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(parts, op) + ")"
+}
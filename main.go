@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -10,16 +11,22 @@ import (
 
 	"github.com/TKyleB/snippetz/internal/auth"
 	"github.com/TKyleB/snippetz/internal/database"
+	"github.com/TKyleB/snippetz/internal/detect"
+	"github.com/TKyleB/snippetz/internal/routes/collections"
 	"github.com/TKyleB/snippetz/internal/routes/snippets"
+	"github.com/TKyleB/snippetz/internal/routes/tags"
 	"github.com/TKyleB/snippetz/internal/routes/users"
+	"github.com/TKyleB/snippetz/internal/search"
 	"github.com/joho/godotenv"
 
 	_ "github.com/lib/pq" // Used to connect to DB
 )
 
 type AppConfig struct {
-	usersHandler    users.UsersHandler
-	snippetsHandler snippets.SnippetsHandler
+	usersHandler       users.UsersHandler
+	snippetsHandler    snippets.SnippetsHandler
+	tagsHandler        tags.TagsHandler
+	collectionsHandler collections.CollectionsHandler
 }
 
 func main() {
@@ -54,10 +61,61 @@ func main() {
 	}
 
 	appConfig := AppConfig{
-		usersHandler:    users.UsersHandler{DbQueries: dbQueries, AuthService: &authService},
-		snippetsHandler: snippets.SnippetsHandler{DbQueries: dbQueries, AuthService: &authService},
+		usersHandler:       users.UsersHandler{DbQueries: dbQueries, AuthService: &authService},
+		snippetsHandler:    snippets.SnippetsHandler{DB: db, DbQueries: dbQueries, AuthService: &authService, Completions: search.NewCompletionIndex(), Detector: detect.New()},
+		tagsHandler:        tags.TagsHandler{DbQueries: dbQueries},
+		collectionsHandler: collections.CollectionsHandler{DB: db, DbQueries: dbQueries, AuthService: &authService},
 	}
 
+	// Snippets saved before the code index existed are tokenized in the background
+	go func() {
+		if err := appConfig.snippetsHandler.BackfillCodeIndex(context.Background()); err != nil {
+			log.Printf("Error indexing snippet code. %v", err)
+		}
+	}()
+	// Completions are loaded before serving so no snippet saved meanwhile is missed
+	if err := appConfig.snippetsHandler.LoadCompletions(context.Background()); err != nil {
+		log.Printf("Error loading search completions. %v", err)
+	}
+	// The language detector learns from public snippets, so it is retrained as they grow
+	go func() {
+		if err := appConfig.snippetsHandler.TrainDetector(context.Background()); err != nil {
+			log.Printf("Error training language detection. %v", err)
+		}
+		for range time.Tick(time.Hour) {
+			if err := appConfig.snippetsHandler.TrainDetector(context.Background()); err != nil {
+				log.Printf("Error training language detection. %v", err)
+			}
+		}
+	}()
+	// Keep the words used for "did you mean" suggestions up to date
+	go func() {
+		if err := dbQueries.RefreshSnippetLexemes(context.Background()); err != nil {
+			log.Printf("Error refreshing search suggestions. %v", err)
+		}
+		for range time.Tick(15 * time.Minute) {
+			if err := dbQueries.RefreshSnippetLexemes(context.Background()); err != nil {
+				log.Printf("Error refreshing search suggestions. %v", err)
+			}
+		}
+	}()
+	// Snippet views are counted in memory and written once a minute
+	go func() {
+		for range time.Tick(time.Minute) {
+			if err := appConfig.snippetsHandler.FlushViewCounts(context.Background()); err != nil {
+				log.Printf("Error saving snippet view counts. %v", err)
+			}
+		}
+	}()
+	// Count the new matches for each saved search
+	go func() {
+		for range time.Tick(time.Hour) {
+			if err := appConfig.snippetsHandler.RecordSavedSearchCounts(context.Background()); err != nil {
+				log.Printf("Error counting saved search matches. %v", err)
+			}
+		}
+	}()
+
 	server := http.Server{
 		Handler: corsMiddleware(mux),
 		Addr:    ":" + port,
@@ -69,11 +127,57 @@ func main() {
 	mux.HandleFunc("POST /api/users/refresh", appConfig.usersHandler.RefreshUserToken)
 	mux.HandleFunc("POST /api/users/logout", appConfig.usersHandler.LogoutUser)
 	mux.HandleFunc("GET /api/users", appConfig.usersHandler.GetUser)
+	mux.HandleFunc("GET /api/users/{username}/stars", appConfig.snippetsHandler.GetUserStars)
 
 	mux.HandleFunc("POST /api/snippets", appConfig.snippetsHandler.CreateSnippet)
 	mux.HandleFunc("GET /api/snippets", appConfig.snippetsHandler.GetSnippets)
 	mux.HandleFunc("GET /api/snippets/{id}", appConfig.snippetsHandler.GetSnippetById)
+	mux.HandleFunc("PATCH /api/snippets/{id}", appConfig.snippetsHandler.UpdateSnippet)
 	mux.HandleFunc("DELETE /api/snippets/{id}", appConfig.snippetsHandler.DeleteSnippetById)
+	mux.HandleFunc("GET /api/snippets/{id}/revisions", appConfig.snippetsHandler.GetSnippetRevisions)
+	mux.HandleFunc("GET /api/snippets/{id}/revisions/{rev}", appConfig.snippetsHandler.GetSnippetRevision)
+	mux.HandleFunc("POST /api/snippets/{id}/revisions/{rev}/restore", appConfig.snippetsHandler.RestoreSnippetRevision)
+	mux.HandleFunc("GET /api/snippets/{id}/diff", appConfig.snippetsHandler.GetSnippetDiff)
+	mux.HandleFunc("POST /api/snippets/{id}/fork", appConfig.snippetsHandler.ForkSnippet)
+	mux.HandleFunc("GET /api/snippets/{id}/forks", appConfig.snippetsHandler.GetSnippetForks)
+	mux.HandleFunc("GET /api/snippets/{id}/raw", appConfig.snippetsHandler.GetSnippetRaw)
+	mux.HandleFunc("GET /api/snippets/{id}/files/{name}", appConfig.snippetsHandler.GetSnippetFile)
+	mux.HandleFunc("GET /api/snippets/{id}/archive", appConfig.snippetsHandler.GetSnippetArchive)
+	mux.HandleFunc("POST /api/snippets/{id}/star", appConfig.snippetsHandler.StarSnippet)
+	mux.HandleFunc("DELETE /api/snippets/{id}/star", appConfig.snippetsHandler.UnstarSnippet)
+	mux.HandleFunc("GET /api/snippets/{id}/comments", appConfig.snippetsHandler.GetSnippetComments)
+	mux.HandleFunc("POST /api/snippets/{id}/comments", appConfig.snippetsHandler.CreateSnippetComment)
+	mux.HandleFunc("PATCH /api/snippets/{id}/comments/{comment_id}", appConfig.snippetsHandler.UpdateSnippetComment)
+	mux.HandleFunc("DELETE /api/snippets/{id}/comments/{comment_id}", appConfig.snippetsHandler.DeleteSnippetComment)
+	mux.HandleFunc("GET /api/snippets/{id}/line-comments", appConfig.snippetsHandler.GetSnippetLineComments)
+	mux.HandleFunc("POST /api/snippets/{id}/line-comments", appConfig.snippetsHandler.CreateSnippetLineComment)
+
+	mux.HandleFunc("GET /api/languages", appConfig.snippetsHandler.GetLanguages)
+	mux.HandleFunc("POST /api/languages", appConfig.snippetsHandler.CreateLanguage)
+	mux.HandleFunc("POST /api/languages/detect", appConfig.snippetsHandler.DetectLanguage)
+	mux.HandleFunc("GET /api/languages/{name}", appConfig.snippetsHandler.GetLanguage)
+	mux.HandleFunc("PATCH /api/languages/{name}", appConfig.snippetsHandler.UpdateLanguage)
+	mux.HandleFunc("DELETE /api/languages/{name}", appConfig.snippetsHandler.DeleteLanguage)
+
+	mux.HandleFunc("GET /api/search/suggest", appConfig.snippetsHandler.GetSearchSuggestions)
+
+	mux.HandleFunc("POST /api/saved-searches", appConfig.snippetsHandler.CreateSavedSearch)
+	mux.HandleFunc("GET /api/saved-searches", appConfig.snippetsHandler.GetSavedSearches)
+	mux.HandleFunc("GET /api/saved-searches/{id}", appConfig.snippetsHandler.GetSavedSearch)
+	mux.HandleFunc("DELETE /api/saved-searches/{id}", appConfig.snippetsHandler.DeleteSavedSearch)
+	mux.HandleFunc("GET /api/saved-searches/{id}/new", appConfig.snippetsHandler.GetSavedSearchNew)
+
+	mux.HandleFunc("GET /api/tags", appConfig.tagsHandler.GetTags)
+
+	mux.HandleFunc("POST /api/collections", appConfig.collectionsHandler.CreateCollection)
+	mux.HandleFunc("GET /api/collections", appConfig.collectionsHandler.GetCollections)
+	mux.HandleFunc("GET /api/collections/{id}", appConfig.collectionsHandler.GetCollectionById)
+	mux.HandleFunc("PATCH /api/collections/{id}", appConfig.collectionsHandler.UpdateCollection)
+	mux.HandleFunc("DELETE /api/collections/{id}", appConfig.collectionsHandler.DeleteCollection)
+	mux.HandleFunc("GET /api/collections/{id}/snippets", appConfig.collectionsHandler.GetCollectionSnippets)
+	mux.HandleFunc("POST /api/collections/{id}/snippets", appConfig.collectionsHandler.AddCollectionSnippet)
+	mux.HandleFunc("PUT /api/collections/{id}/snippets", appConfig.collectionsHandler.ReorderCollectionSnippets)
+	mux.HandleFunc("DELETE /api/collections/{id}/snippets/{snippet_id}", appConfig.collectionsHandler.RemoveCollectionSnippet)
 
 	fmt.Printf("Starting server on %s\n", server.Addr)
 	http.ListenAndServe(server.Addr, server.Handler)
@@ -84,8 +188,9 @@ func corsMiddleware(next http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Access-Control-Allow-Origin", "*")
 		w.Header().Add("Access-Control-Allow-Credentials", "false")
-		w.Header().Add("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		w.Header().Add("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
+		w.Header().Add("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, If-Match")
+		w.Header().Add("Access-Control-Expose-Headers", "ETag")
+		w.Header().Add("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, PATCH, DELETE")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
@@ -0,0 +1,94 @@
+-- name: CreateCollection :one
+WITH inserted_collection AS (
+INSERT INTO collections(id, created_at, updated_at, user_id, name, description, visibility)
+VALUES(gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4)
+RETURNING *
+)
+SELECT inserted_collection.*, users.username
+FROM inserted_collection
+INNER JOIN users ON users.id = inserted_collection.user_id;
+
+-- name: GetCollectionById :one
+SELECT collections.id, collections.created_at, collections.updated_at, collections.user_id, users.username, collections.name, collections.description, collections.visibility,
+ (SELECT COUNT(*) FROM collection_snippets WHERE collection_snippets.collection_id = collections.id) AS snippet_count
+FROM collections
+INNER JOIN users ON collections.user_id = users.id
+WHERE collections.id = $1;
+
+-- name: GetCollections :many
+SELECT COUNT(*) OVER () AS total_count,
+ collections.id, collections.created_at, collections.updated_at, collections.user_id, users.username, collections.name, collections.description, collections.visibility,
+ (SELECT COUNT(*) FROM collection_snippets WHERE collection_snippets.collection_id = collections.id) AS snippet_count
+FROM collections
+INNER JOIN users ON collections.user_id = users.id
+WHERE
+((sqlc.narg('owner_id')::uuid IS NULL AND collections.visibility = 'public') OR collections.user_id = sqlc.narg('owner_id'))
+AND (users.username = sqlc.narg('username') OR sqlc.narg('username') IS NULL)
+ORDER BY collections.updated_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: UpdateCollection :one
+WITH updated_collection AS (
+UPDATE collections
+SET name = COALESCE(sqlc.narg('name'), name),
+    description = COALESCE(sqlc.narg('description'), description),
+    visibility = COALESCE(sqlc.narg('visibility'), visibility),
+    updated_at = NOW()
+WHERE collections.id = sqlc.arg('id')
+RETURNING *
+)
+SELECT updated_collection.*, users.username,
+ (SELECT COUNT(*) FROM collection_snippets WHERE collection_snippets.collection_id = updated_collection.id) AS snippet_count
+FROM updated_collection
+INNER JOIN users ON users.id = updated_collection.user_id;
+
+-- name: DeleteCollection :exec
+DELETE FROM collections WHERE collections.id = $1;
+
+-- name: LockCollection :exec
+SELECT collections.id FROM collections WHERE collections.id = $1 FOR UPDATE;
+
+-- name: AddCollectionSnippet :exec
+INSERT INTO collection_snippets(collection_id, snippet_id, position, added_at)
+VALUES(
+    sqlc.arg('collection_id'),
+    sqlc.arg('snippet_id'),
+    (SELECT COALESCE(MAX(position), -1) + 1 FROM collection_snippets WHERE collection_snippets.collection_id = sqlc.arg('collection_id')),
+    NOW()
+)
+ON CONFLICT (collection_id, snippet_id) DO NOTHING;
+
+-- name: RemoveCollectionSnippet :execrows
+DELETE FROM collection_snippets
+WHERE collection_snippets.collection_id = $1
+AND collection_snippets.snippet_id = $2;
+
+-- name: GetCollectionSnippetIds :many
+SELECT collection_snippets.snippet_id
+FROM collection_snippets
+WHERE collection_snippets.collection_id = $1
+ORDER BY collection_snippets.position;
+
+-- name: ReorderCollectionSnippets :exec
+UPDATE collection_snippets
+SET position = new_order.position - 1
+FROM unnest(sqlc.arg('snippet_ids')::text[]) WITH ORDINALITY AS new_order(snippet_id, position)
+WHERE collection_snippets.collection_id = sqlc.arg('collection_id')
+AND collection_snippets.snippet_id = new_order.snippet_id::uuid;
+
+-- name: GetCollectionSnippets :many
+SELECT COUNT(*) OVER () AS total_count,
+ snippets.id, snippets.created_at, snippets.updated_at, snippets.user_id, snippet_text, users.username, snippet_description, snippet_title, snippets.visibility, snippets.forked_from_id, languages.name AS language,
+ (SELECT COUNT(*) FROM snippets AS forks WHERE forks.forked_from_id = snippets.id AND forks.visibility = 'public') AS fork_count,
+ COALESCE((SELECT array_agg(tags.name ORDER BY tags.name) FROM tags INNER JOIN snippet_tags ON snippet_tags.tag_id = tags.id WHERE snippet_tags.snippet_id = snippets.id), '{}')::text[] AS tags,
+ snippets.star_count,
+ snippets.view_count,
+ EXISTS(SELECT 1 FROM snippet_stars WHERE snippet_stars.snippet_id = snippets.id AND snippet_stars.user_id = sqlc.narg('viewer_id')) AS starred_by_me
+FROM collection_snippets
+INNER JOIN snippets ON collection_snippets.snippet_id = snippets.id
+INNER JOIN languages ON snippets.language_id = languages.id
+INNER JOIN users ON snippets.user_id = users.id
+WHERE collection_snippets.collection_id = sqlc.arg('collection_id')
+AND (snippets.visibility <> 'private' OR snippets.user_id = sqlc.narg('viewer_id'))
+ORDER BY collection_snippets.position
+LIMIT $1 OFFSET $2;
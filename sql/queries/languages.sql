@@ -1,8 +1,60 @@
 -- name: CreateLanguage :one
-INSERT INTO languages(id, name)
-VALUES(gen_random_uuid(), $1)
+INSERT INTO languages(id, name, display_name, extensions, mime_type, line_comment, block_comment_start, block_comment_end)
+VALUES(gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7)
 returning *;
 
 -- name: GetLanguageByName :one
-SELECT id FROM languages
-WHERE name = $1;
\ No newline at end of file
+SELECT languages.* FROM languages
+INNER JOIN language_aliases ON language_aliases.language_id = languages.id
+WHERE language_aliases.alias = lower(sqlc.arg('name'));
+
+-- name: UpdateLanguage :one
+UPDATE languages SET name = $2, display_name = $3, extensions = $4, mime_type = $5, line_comment = $6, block_comment_start = $7, block_comment_end = $8
+WHERE languages.id = $1
+RETURNING *;
+
+-- name: DeleteLanguage :execrows
+DELETE FROM languages
+WHERE languages.id = $1
+AND NOT EXISTS (SELECT 1 FROM snippets WHERE snippets.language_id = languages.id)
+AND NOT EXISTS (SELECT 1 FROM snippet_files WHERE snippet_files.language_id = languages.id)
+AND NOT EXISTS (SELECT 1 FROM snippet_revisions WHERE snippet_revisions.language_id = languages.id);
+
+-- name: GetLanguageAliases :many
+SELECT language_aliases.alias
+FROM language_aliases
+INNER JOIN languages ON languages.id = language_aliases.language_id
+WHERE language_aliases.language_id = $1
+AND language_aliases.alias <> lower(languages.name)
+ORDER BY language_aliases.alias;
+
+-- name: SetLanguageAliases :exec
+INSERT INTO language_aliases(alias, language_id)
+SELECT new_aliases.alias, sqlc.arg('language_id')
+FROM unnest(sqlc.arg('aliases')::text[]) AS new_aliases(alias);
+
+-- name: DeleteLanguageAliases :exec
+DELETE FROM language_aliases WHERE language_aliases.language_id = $1;
+
+-- name: GetLanguages :many
+SELECT languages.*,
+ COALESCE((SELECT array_agg(language_aliases.alias ORDER BY language_aliases.alias) FROM language_aliases WHERE language_aliases.language_id = languages.id AND language_aliases.alias <> lower(languages.name)), '{}')::text[] AS aliases,
+ COUNT(snippets.id) AS snippet_count,
+ MAX(snippets.created_at)::timestamp AS latest_snippet_at
+FROM languages
+LEFT JOIN snippets ON snippets.language_id = languages.id AND snippets.visibility = 'public'
+GROUP BY languages.id
+ORDER BY languages.name;
+
+-- name: GetLanguageSamples :many
+SELECT languages.name AS language, samples.file_text
+FROM languages
+CROSS JOIN LATERAL (
+    SELECT left(snippet_files.file_text, sqlc.arg('max_length')::int) AS file_text
+    FROM snippet_files
+    INNER JOIN snippets ON snippets.id = snippet_files.snippet_id
+    WHERE snippet_files.language_id = languages.id AND snippets.visibility = 'public'
+    AND NOT snippet_files.language_detected
+    ORDER BY snippets.created_at DESC
+    LIMIT sqlc.arg('per_language')
+) AS samples;
@@ -0,0 +1,31 @@
+-- name: CreateSavedSearch :one
+INSERT INTO saved_searches(id, created_at, updated_at, user_id, name, query, last_checked_at)
+VALUES(gen_random_uuid(), NOW(), NOW(), $1, $2, $3, NOW())
+RETURNING *;
+
+-- name: GetSavedSearches :many
+SELECT * FROM saved_searches
+WHERE saved_searches.user_id = $1
+ORDER BY saved_searches.name;
+
+-- name: GetSavedSearch :one
+SELECT * FROM saved_searches
+WHERE saved_searches.id = $1 AND saved_searches.user_id = $2;
+
+-- name: DeleteSavedSearch :exec
+DELETE FROM saved_searches
+WHERE saved_searches.id = $1 AND saved_searches.user_id = $2;
+
+-- name: MarkSavedSearchChecked :exec
+UPDATE saved_searches SET last_checked_at = NOW(), new_count = 0
+WHERE saved_searches.id = $1;
+
+-- name: GetSavedSearchesAfter :many
+SELECT * FROM saved_searches
+WHERE saved_searches.id > $1
+ORDER BY saved_searches.id
+LIMIT $2;
+
+-- name: SetSavedSearchNewCount :exec
+UPDATE saved_searches SET new_count = $2, counted_at = NOW()
+WHERE saved_searches.id = $1 AND saved_searches.last_checked_at = $3;
@@ -0,0 +1,47 @@
+-- name: CreateSnippetComment :one
+WITH inserted_comment AS (
+INSERT INTO snippet_comments(id, created_at, updated_at, snippet_id, user_id, parent_id, body, revision, file_name, start_line, end_line, current_start_line, current_end_line, outdated)
+VALUES(gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING *
+)
+SELECT inserted_comment.*, users.username
+FROM inserted_comment
+INNER JOIN users ON users.id = inserted_comment.user_id;
+
+-- name: GetSnippetComments :many
+SELECT snippet_comments.*, users.username
+FROM snippet_comments
+INNER JOIN users ON users.id = snippet_comments.user_id
+WHERE snippet_comments.snippet_id = $1
+AND (snippet_comments.start_line IS NOT NULL) = sqlc.arg('anchored')::boolean
+ORDER BY snippet_comments.created_at, snippet_comments.id;
+
+-- name: GetSnippetComment :one
+SELECT snippet_comments.*, users.username
+FROM snippet_comments
+INNER JOIN users ON users.id = snippet_comments.user_id
+WHERE snippet_comments.id = $1
+AND snippet_comments.snippet_id = $2;
+
+-- name: UpdateSnippetComment :one
+WITH updated_comment AS (
+UPDATE snippet_comments
+SET body = $1, updated_at = NOW()
+WHERE snippet_comments.id = $2
+AND snippet_comments.deleted_at IS NULL
+RETURNING *
+)
+SELECT updated_comment.*, users.username
+FROM updated_comment
+INNER JOIN users ON users.id = updated_comment.user_id;
+
+-- name: DeleteSnippetComment :exec
+UPDATE snippet_comments
+SET deleted_at = NOW(), deleted_by = $2
+WHERE snippet_comments.id = $1
+AND snippet_comments.deleted_at IS NULL;
+
+-- name: UpdateSnippetCommentAnchor :exec
+UPDATE snippet_comments
+SET current_start_line = $1, current_end_line = $2, outdated = $3
+WHERE snippet_comments.id = $4;
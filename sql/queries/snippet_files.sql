@@ -0,0 +1,50 @@
+-- name: CreateSnippetFile :one
+INSERT INTO snippet_files(id, snippet_id, position, file_name, language_id, file_text, created_at, updated_at, language_detected)
+VALUES(gen_random_uuid(), $1, $2, $3, $4, $5, NOW(), NOW(), $6)
+RETURNING *;
+
+-- name: GetSnippetFiles :many
+SELECT snippet_files.position, snippet_files.file_name, snippet_files.file_text, languages.name AS language
+FROM snippet_files
+INNER JOIN languages ON snippet_files.language_id = languages.id
+WHERE snippet_files.snippet_id = $1
+ORDER BY snippet_files.position;
+
+-- name: GetSnippetFileByName :one
+SELECT snippet_files.position, snippet_files.file_name, snippet_files.file_text, languages.name AS language
+FROM snippet_files
+INNER JOIN languages ON snippet_files.language_id = languages.id
+WHERE snippet_files.snippet_id = $1
+AND snippet_files.file_name = $2;
+
+-- name: DeleteSnippetFiles :exec
+DELETE FROM snippet_files WHERE snippet_files.snippet_id = $1;
+
+-- name: UpdatePrimarySnippetFile :exec
+UPDATE snippet_files
+SET file_text = COALESCE(sqlc.narg('file_text'), file_text),
+    language_id = COALESCE(sqlc.narg('language_id'), language_id),
+    language_detected = language_detected AND sqlc.narg('language_id')::uuid IS NULL,
+    updated_at = NOW()
+WHERE snippet_files.snippet_id = sqlc.arg('snippet_id')
+AND snippet_files.position = 0;
+
+-- name: CopySnippetFiles :exec
+INSERT INTO snippet_files(id, snippet_id, position, file_name, language_id, file_text, created_at, updated_at, language_detected)
+SELECT gen_random_uuid(), sqlc.arg('snippet_id'), source_files.position, source_files.file_name, source_files.language_id, source_files.file_text, NOW(), NOW(), source_files.language_detected
+FROM snippet_files AS source_files
+WHERE source_files.snippet_id = sqlc.arg('source_id');
+
+-- name: RestoreSnippetFiles :exec
+INSERT INTO snippet_files(id, snippet_id, position, file_name, language_id, file_text, created_at, updated_at, language_detected)
+SELECT gen_random_uuid(), snippet_revisions.snippet_id, revision_files.position, revision_files.file_name, revision_files.language_id, revision_files.file_text, NOW(), NOW(), COALESCE(revision_files.language_detected, false)
+FROM snippet_revisions
+CROSS JOIN LATERAL jsonb_to_recordset(snippet_revisions.files) AS revision_files(position INTEGER, file_name TEXT, language_id uuid, file_text TEXT, language_detected BOOLEAN)
+WHERE snippet_revisions.snippet_id = $1
+AND snippet_revisions.revision = $2;
+
+-- name: GetFilesOfSnippets :many
+SELECT snippet_files.snippet_id, snippet_files.file_name, snippet_files.file_text
+FROM snippet_files
+WHERE snippet_files.snippet_id = ANY(sqlc.arg('snippet_ids')::text[]::uuid[])
+ORDER BY snippet_files.snippet_id, snippet_files.position;
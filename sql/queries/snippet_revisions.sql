@@ -0,0 +1,53 @@
+-- name: GetSnippetRevisions :many
+SELECT snippet_revisions.revision, snippet_revisions.created_at, snippet_revisions.user_id, users.username, snippet_title, snippet_description, languages.name AS language
+FROM snippet_revisions
+INNER JOIN languages ON snippet_revisions.language_id = languages.id
+INNER JOIN users ON snippet_revisions.user_id = users.id
+WHERE snippet_revisions.snippet_id = $1
+ORDER BY snippet_revisions.revision DESC;
+
+-- name: GetSnippetRevision :one
+SELECT snippet_revisions.revision, snippet_revisions.created_at, snippet_revisions.user_id, users.username, snippet_revisions.language_id, snippet_title, snippet_description, snippet_text, snippet_revisions.files, languages.name AS language
+FROM snippet_revisions
+INNER JOIN languages ON snippet_revisions.language_id = languages.id
+INNER JOIN users ON snippet_revisions.user_id = users.id
+WHERE snippet_revisions.snippet_id = $1
+AND snippet_revisions.revision = $2;
+
+-- name: CreateSnippetRevision :exec
+WITH snapshot AS (
+    SELECT snippets.id, snippets.updated_at, snippets.user_id, snippets.language_id, snippets.snippet_title, snippets.snippet_description, snippets.snippet_text,
+        COALESCE((
+            SELECT jsonb_agg(jsonb_build_object(
+                'position', snippet_files.position,
+                'file_name', snippet_files.file_name,
+                'language_id', snippet_files.language_id,
+                'language', languages.name,
+                'file_text', snippet_files.file_text,
+                'language_detected', snippet_files.language_detected
+            ) ORDER BY snippet_files.position)
+            FROM snippet_files
+            INNER JOIN languages ON languages.id = snippet_files.language_id
+            WHERE snippet_files.snippet_id = snippets.id
+        ), '[]'::jsonb) AS files
+    FROM snippets
+    WHERE snippets.id = sqlc.arg('snippet_id')
+),
+latest AS (
+    SELECT snippet_revisions.revision, snippet_revisions.language_id, snippet_revisions.snippet_title, snippet_revisions.snippet_description, snippet_revisions.snippet_text, snippet_revisions.files
+    FROM snippet_revisions
+    WHERE snippet_revisions.snippet_id = sqlc.arg('snippet_id')
+    ORDER BY snippet_revisions.revision DESC
+    LIMIT 1
+)
+INSERT INTO snippet_revisions(id, snippet_id, revision, created_at, user_id, language_id, snippet_title, snippet_description, snippet_text, files)
+SELECT gen_random_uuid(), snapshot.id, COALESCE((SELECT latest.revision FROM latest), 0) + 1, snapshot.updated_at, snapshot.user_id, snapshot.language_id, snapshot.snippet_title, snapshot.snippet_description, snapshot.snippet_text, snapshot.files
+FROM snapshot
+WHERE NOT EXISTS (
+    SELECT 1 FROM latest
+    WHERE latest.language_id = snapshot.language_id
+    AND latest.snippet_title = snapshot.snippet_title
+    AND latest.snippet_description = snapshot.snippet_description
+    AND latest.snippet_text = snapshot.snippet_text
+    AND latest.files = snapshot.files
+);
@@ -0,0 +1,36 @@
+-- name: StarSnippet :exec
+INSERT INTO snippet_stars(user_id, snippet_id, created_at)
+VALUES($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnstarSnippet :exec
+DELETE FROM snippet_stars
+WHERE snippet_stars.user_id = $1
+AND snippet_stars.snippet_id = $2;
+
+-- name: IsSnippetStarred :one
+SELECT EXISTS(
+    SELECT 1 FROM snippet_stars
+    WHERE snippet_stars.user_id = $1
+    AND snippet_stars.snippet_id = $2
+) AS starred;
+
+-- name: GetSnippetStarCount :one
+SELECT snippets.star_count FROM snippets WHERE snippets.id = $1;
+
+-- name: GetStarredSnippets :many
+SELECT COUNT(*) OVER () AS total_count,
+ snippets.id, snippets.created_at, snippets.updated_at, snippets.user_id, snippet_text, users.username, snippet_description, snippet_title, snippets.visibility, snippets.forked_from_id, languages.name AS language,
+ (SELECT COUNT(*) FROM snippets AS forks WHERE forks.forked_from_id = snippets.id AND forks.visibility = 'public') AS fork_count,
+ COALESCE((SELECT array_agg(tags.name ORDER BY tags.name) FROM tags INNER JOIN snippet_tags ON snippet_tags.tag_id = tags.id WHERE snippet_tags.snippet_id = snippets.id), '{}')::text[] AS tags,
+ snippets.star_count,
+ snippets.view_count,
+ EXISTS(SELECT 1 FROM snippet_stars AS viewer_stars WHERE viewer_stars.snippet_id = snippets.id AND viewer_stars.user_id = sqlc.narg('viewer_id')) AS starred_by_me
+FROM snippet_stars
+INNER JOIN snippets ON snippet_stars.snippet_id = snippets.id
+INNER JOIN languages ON snippets.language_id = languages.id
+INNER JOIN users ON snippets.user_id = users.id
+WHERE snippet_stars.user_id = sqlc.arg('user_id')
+AND (snippets.visibility = 'public' OR snippets.user_id = sqlc.narg('viewer_id'))
+ORDER BY snippet_stars.created_at DESC
+LIMIT $1 OFFSET $2;
@@ -1,29 +1,70 @@
 -- name: CreateSnippet :one
 WITH inserted_snippet AS (
-INSERT INTO snippets(id, created_at, updated_at, language_id, user_id, snippet_text, snippet_description, snippet_title)
-VALUES(gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, $5)
+INSERT INTO snippets(id, created_at, updated_at, language_id, user_id, snippet_text, snippet_description, snippet_title, visibility)
+VALUES(gen_random_uuid(), NOW(), NOW(), $1, $2, $3, $4, $5, $6)
 RETURNING *
 )
 SELECT inserted_snippet.*, users.username
 FROM inserted_snippet
 INNER JOIN users ON users.id = inserted_snippet.user_id;
 
--- name: GetSnippetsByCreatedAt :many
-SELECT COUNT(*) OVER () AS total_count,
- snippets.id, snippets.created_at, snippets.updated_at, snippets.user_id, snippet_text, users.username, snippet_description, snippet_title, languages.name AS language
-FROM snippets
-INNER JOIN languages ON snippets.language_id = languages.id
-INNER JOIN users ON snippets.user_id = users.id
-WHERE
-(languages.name = sqlc.narg('language') OR sqlc.narg('language') IS NULL)
-AND (users.username = sqlc.narg('username') OR sqlc.narg('username') IS NULL)
-AND (search_vector @@ to_tsquery('simple', sqlc.narg('search')) OR sqlc.narg('search') IS NULL)
-ORDER BY snippets.created_at DESC
-LIMIT $1 OFFSET $2;
+-- name: GetSnippetFacets :many
+WITH filtered AS (
+    SELECT filtered.id, filtered.created_at, languages.name AS language, users.username
+    FROM filter_snippets(
+        sqlc.narg('owner_id'),
+        sqlc.narg('languages')::text[],
+        sqlc.narg('usernames')::text[],
+        sqlc.narg('created_after')::timestamp,
+        sqlc.narg('created_before')::timestamp,
+        sqlc.narg('updated_since')::timestamp,
+        sqlc.narg('has_forks')::boolean,
+        sqlc.narg('min_stars')::int,
+        sqlc.narg('search')::tsquery,
+        sqlc.narg('fuzzy')::text,
+        sqlc.narg('substring')::text,
+        sqlc.narg('regex_literal')::text,
+        sqlc.narg('tags')::text[],
+        sqlc.arg('match_all_tags')::boolean
+    ) AS filtered
+    INNER JOIN languages ON filtered.language_id = languages.id
+    INNER JOIN users ON filtered.user_id = users.id
+)
+SELECT 'language'::text AS facet, filtered.language::text AS value, COUNT(*) AS count FROM filtered GROUP BY filtered.language
+UNION ALL
+SELECT 'author'::text, filtered.username::text, COUNT(*) FROM filtered GROUP BY filtered.username
+UNION ALL
+SELECT 'tag'::text, tags.name::text, COUNT(*) FROM filtered
+INNER JOIN snippet_tags ON snippet_tags.snippet_id = filtered.id
+INNER JOIN tags ON tags.id = snippet_tags.tag_id
+GROUP BY tags.name
+UNION ALL
+SELECT 'year'::text, EXTRACT(YEAR FROM filtered.created_at)::int::text, COUNT(*) FROM filtered GROUP BY EXTRACT(YEAR FROM filtered.created_at);
 
+-- name: CountSnippets :one
+SELECT COUNT(*) FROM filter_snippets(
+    sqlc.narg('owner_id'),
+    sqlc.narg('languages')::text[],
+    sqlc.narg('usernames')::text[],
+    sqlc.narg('created_after')::timestamp,
+    sqlc.narg('created_before')::timestamp,
+    sqlc.narg('updated_since')::timestamp,
+    sqlc.narg('has_forks')::boolean,
+    sqlc.narg('min_stars')::int,
+    sqlc.narg('search')::tsquery,
+    sqlc.narg('fuzzy')::text,
+    sqlc.narg('substring')::text,
+    sqlc.narg('regex_literal')::text,
+    sqlc.narg('tags')::text[],
+    sqlc.arg('match_all_tags')::boolean
+) AS filtered;
 
 -- name: GetSnippetById :one
-SELECT snippets.id, snippets.created_at, snippets.updated_at, snippets.user_id, snippet_text, users.username, snippet_description, snippet_title, languages.name AS language
+SELECT snippets.id, snippets.created_at, snippets.updated_at, snippets.user_id, snippet_text, users.username, snippet_description, snippet_title, snippets.visibility, snippets.forked_from_id, languages.name AS language,
+ (SELECT COUNT(*) FROM snippets AS forks WHERE forks.forked_from_id = snippets.id AND forks.visibility = 'public') AS fork_count,
+ COALESCE((SELECT array_agg(tags.name ORDER BY tags.name) FROM tags INNER JOIN snippet_tags ON snippet_tags.tag_id = tags.id WHERE snippet_tags.snippet_id = snippets.id), '{}')::text[] AS tags,
+ snippets.star_count,
+ snippets.view_count
 FROM snippets
 INNER JOIN languages ON snippets.language_id = languages.id
 INNER JOIN users ON snippets.user_id = users.id
@@ -34,10 +75,107 @@ SELECT snippets.id, snippets.created_at, snippets.updated_at, snippets.user_id,
 FROM snippets
 INNER JOIN languages ON snippets.language_id = languages.id
 INNER JOIN users ON snippets.user_id = users.id
-WHERE search_vector @@ to_tsquery('simple', $1);
+WHERE search_vector @@ to_tsquery('simple', $1)
+AND snippets.visibility = 'public';
 
 -- name: DeleteSnippetById :exec
 DELETE FROM snippets WHERE snippets.id = $1;
 
 
 
+-- name: UpdateSnippet :one
+WITH updated_snippet AS (
+UPDATE snippets
+SET language_id = COALESCE(sqlc.narg('language_id'), language_id),
+    snippet_title = COALESCE(sqlc.narg('snippet_title'), snippet_title),
+    snippet_description = COALESCE(sqlc.narg('snippet_description'), snippet_description),
+    snippet_text = COALESCE(sqlc.narg('snippet_text'), snippet_text),
+    visibility = COALESCE(sqlc.narg('visibility'), visibility),
+    updated_at = NOW()
+WHERE snippets.id = sqlc.arg('id')
+AND snippets.updated_at = sqlc.arg('updated_at')
+RETURNING *
+)
+SELECT updated_snippet.*, users.username, languages.name AS language
+FROM updated_snippet
+INNER JOIN users ON users.id = updated_snippet.user_id
+INNER JOIN languages ON languages.id = updated_snippet.language_id;
+
+-- name: ForkSnippet :one
+WITH inserted_snippet AS (
+INSERT INTO snippets(id, created_at, updated_at, language_id, user_id, snippet_text, snippet_description, snippet_title, visibility, forked_from_id)
+SELECT gen_random_uuid(), NOW(), NOW(), snippets.language_id, sqlc.arg('user_id'), snippets.snippet_text, snippets.snippet_description, snippets.snippet_title, snippets.visibility, snippets.id
+FROM snippets
+WHERE snippets.id = sqlc.arg('id')
+RETURNING *
+)
+SELECT inserted_snippet.*, users.username, languages.name AS language
+FROM inserted_snippet
+INNER JOIN users ON users.id = inserted_snippet.user_id
+INNER JOIN languages ON languages.id = inserted_snippet.language_id;
+
+-- name: GetSnippetForks :many
+SELECT snippets.id, snippets.created_at, snippets.updated_at, snippets.user_id, snippet_text, users.username, snippet_description, snippet_title, snippets.visibility, snippets.forked_from_id, languages.name AS language,
+ (SELECT COUNT(*) FROM snippets AS forks WHERE forks.forked_from_id = snippets.id AND forks.visibility = 'public') AS fork_count,
+ COALESCE((SELECT array_agg(tags.name ORDER BY tags.name) FROM tags INNER JOIN snippet_tags ON snippet_tags.tag_id = tags.id WHERE snippet_tags.snippet_id = snippets.id), '{}')::text[] AS tags,
+ snippets.star_count,
+ snippets.view_count,
+ EXISTS(SELECT 1 FROM snippet_stars WHERE snippet_stars.snippet_id = snippets.id AND snippet_stars.user_id = sqlc.narg('viewer_id')) AS starred_by_me
+FROM snippets
+INNER JOIN languages ON snippets.language_id = languages.id
+INNER JOIN users ON snippets.user_id = users.id
+WHERE snippets.forked_from_id = sqlc.arg('id')
+AND (snippets.visibility = 'public' OR snippets.user_id = sqlc.narg('viewer_id'))
+ORDER BY snippets.created_at DESC;
+
+-- name: GetSnippetAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT snippets.id, snippets.forked_from_id, 0 AS depth
+    FROM snippets
+    WHERE snippets.id = $1
+    UNION ALL
+    SELECT snippets.id, snippets.forked_from_id, ancestors.depth + 1
+    FROM snippets
+    INNER JOIN ancestors ON snippets.id = ancestors.forked_from_id
+    WHERE ancestors.depth < 100
+)
+SELECT snippets.id, snippets.user_id, users.username, snippets.snippet_title, snippets.visibility
+FROM ancestors
+INNER JOIN snippets ON snippets.id = ancestors.id
+INNER JOIN users ON users.id = snippets.user_id
+WHERE ancestors.depth > 0
+ORDER BY ancestors.depth;
+
+-- name: SetSnippetSearchVector :exec
+UPDATE snippets
+SET search_vector = snippet_search_vector(snippets.id, snippets.snippet_title, snippets.snippet_description, snippets.snippet_text)
+WHERE snippets.id = $1;
+
+-- name: SetSnippetCodeVector :exec
+UPDATE snippets SET code_vector = sqlc.arg('code_vector')::text::tsvector WHERE snippets.id = sqlc.arg('id');
+
+-- name: GetSnippetsWithoutCodeVector :many
+SELECT snippets.id FROM snippets WHERE snippets.code_vector IS NULL AND snippets.id > sqlc.arg('after_id') ORDER BY snippets.id LIMIT $1;
+
+-- name: GetClosestLexeme :one
+SELECT snippet_lexemes.word
+FROM snippet_lexemes
+WHERE snippet_lexemes.word % sqlc.arg('query')::text
+ORDER BY similarity(snippet_lexemes.word, sqlc.arg('query')::text) DESC, snippet_lexemes.ndoc DESC
+LIMIT 1;
+
+-- name: RefreshSnippetLexemes :exec
+REFRESH MATERIALIZED VIEW CONCURRENTLY snippet_lexemes;
+
+-- name: AddSnippetViewCounts :exec
+UPDATE snippets SET view_count = snippets.view_count + counts.views
+FROM unnest(sqlc.arg('snippet_ids')::text[], sqlc.arg('views')::int[]) AS counts(snippet_id, views)
+WHERE snippets.id = counts.snippet_id::uuid;
+
+-- name: GetPublicSnippetTerms :many
+SELECT snippets.id, snippets.snippet_title, users.username, languages.name AS language,
+ COALESCE((SELECT array_agg(tags.name ORDER BY tags.name) FROM tags INNER JOIN snippet_tags ON snippet_tags.tag_id = tags.id WHERE snippet_tags.snippet_id = snippets.id), '{}')::text[] AS tags
+FROM snippets
+INNER JOIN users ON users.id = snippets.user_id
+INNER JOIN languages ON languages.id = snippets.language_id
+WHERE snippets.visibility = 'public';
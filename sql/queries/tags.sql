@@ -0,0 +1,39 @@
+-- name: CreateTags :exec
+INSERT INTO tags(id, created_at, name)
+SELECT gen_random_uuid(), NOW(), tag_names.name
+FROM unnest(sqlc.arg('names')::text[]) AS tag_names(name)
+ON CONFLICT (name) DO NOTHING;
+
+-- name: AddSnippetTags :exec
+INSERT INTO snippet_tags(snippet_id, tag_id)
+SELECT sqlc.arg('snippet_id'), tags.id
+FROM tags
+WHERE tags.name = ANY(sqlc.arg('names')::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: CopySnippetTags :exec
+INSERT INTO snippet_tags(snippet_id, tag_id)
+SELECT sqlc.arg('snippet_id'), source_tags.tag_id
+FROM snippet_tags AS source_tags
+WHERE source_tags.snippet_id = sqlc.arg('source_id');
+
+-- name: DeleteSnippetTags :exec
+DELETE FROM snippet_tags WHERE snippet_tags.snippet_id = $1;
+
+-- name: GetSnippetTags :many
+SELECT tags.name
+FROM tags
+INNER JOIN snippet_tags ON snippet_tags.tag_id = tags.id
+WHERE snippet_tags.snippet_id = $1
+ORDER BY tags.name;
+
+-- name: GetTagCounts :many
+SELECT tags.name, COUNT(*) AS count
+FROM tags
+INNER JOIN snippet_tags ON snippet_tags.tag_id = tags.id
+INNER JOIN snippets ON snippets.id = snippet_tags.snippet_id
+WHERE snippets.visibility = 'public'
+AND (tags.name LIKE sqlc.narg('prefix') || '%' OR sqlc.narg('prefix') IS NULL)
+GROUP BY tags.name
+ORDER BY count DESC, tags.name
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,58 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE snippet_revisions(
+    id uuid PRIMARY KEY,
+    snippet_id uuid NOT NULL,
+    revision INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    user_id uuid NOT NULL,
+    language_id uuid NOT NULL,
+    snippet_title TEXT NOT NULL,
+    snippet_description TEXT NOT NULL,
+    snippet_text TEXT NOT NULL,
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (language_id) REFERENCES languages(id) ON DELETE CASCADE,
+    UNIQUE (snippet_id, revision)
+);
+INSERT INTO snippet_revisions(id, snippet_id, revision, created_at, user_id, language_id, snippet_title, snippet_description, snippet_text)
+SELECT gen_random_uuid(), id, 1, updated_at, user_id, language_id, snippet_title, snippet_description, snippet_text FROM snippets;
+CREATE OR REPLACE FUNCTION record_snippet_revision() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO snippet_revisions(id, snippet_id, revision, created_at, user_id, language_id, snippet_title, snippet_description, snippet_text)
+    VALUES(
+        gen_random_uuid(),
+        NEW.id,
+        (SELECT COALESCE(MAX(revision), 0) + 1 FROM snippet_revisions WHERE snippet_id = NEW.id),
+        NEW.updated_at,
+        NEW.user_id,
+        NEW.language_id,
+        NEW.snippet_title,
+        NEW.snippet_description,
+        NEW.snippet_text
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER snippet_revision_insert
+AFTER INSERT ON snippets
+FOR EACH ROW EXECUTE FUNCTION record_snippet_revision();
+CREATE TRIGGER snippet_revision_update
+AFTER UPDATE ON snippets
+FOR EACH ROW
+WHEN (
+    OLD.language_id IS DISTINCT FROM NEW.language_id
+    OR OLD.snippet_title IS DISTINCT FROM NEW.snippet_title
+    OR OLD.snippet_description IS DISTINCT FROM NEW.snippet_description
+    OR OLD.snippet_text IS DISTINCT FROM NEW.snippet_text
+)
+EXECUTE FUNCTION record_snippet_revision();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER snippet_revision_update ON snippets;
+DROP TRIGGER snippet_revision_insert ON snippets;
+DROP FUNCTION record_snippet_revision();
+DROP TABLE snippet_revisions;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE snippets
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+CHECK (visibility IN ('public', 'unlisted', 'private'));
+CREATE INDEX idx_snippets_visibility_created_at ON snippets(visibility, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_snippets_visibility_created_at;
+ALTER TABLE snippets
+DROP COLUMN visibility;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE snippets
+ADD COLUMN forked_from_id uuid REFERENCES snippets(id) ON DELETE SET NULL;
+CREATE INDEX idx_snippets_forked_from_id ON snippets(forked_from_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_snippets_forked_from_id;
+ALTER TABLE snippets
+DROP COLUMN forked_from_id;
+-- +goose StatementEnd
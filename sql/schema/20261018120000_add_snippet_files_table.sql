@@ -0,0 +1,106 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE snippet_files(
+    id uuid PRIMARY KEY,
+    snippet_id uuid NOT NULL,
+    position INTEGER NOT NULL,
+    file_name TEXT NOT NULL,
+    language_id uuid NOT NULL,
+    file_text TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE,
+    FOREIGN KEY (language_id) REFERENCES languages(id) ON DELETE CASCADE,
+    UNIQUE (snippet_id, file_name),
+    UNIQUE (snippet_id, position)
+);
+INSERT INTO snippet_files(id, snippet_id, position, file_name, language_id, file_text, created_at, updated_at)
+SELECT gen_random_uuid(), snippets.id, 0,
+    'snippet' || CASE languages.name
+        WHEN 'python' THEN '.py'
+        WHEN 'javascript' THEN '.js'
+        WHEN 'go' THEN '.go'
+        WHEN 'sql' THEN '.sql'
+        WHEN 'java' THEN '.java'
+        ELSE '.txt'
+    END,
+    snippets.language_id, snippets.snippet_text, snippets.created_at, snippets.updated_at
+FROM snippets
+INNER JOIN languages ON languages.id = snippets.language_id;
+
+-- Revisions are recorded explicitly once a snippet and all of its files have been written
+DROP TRIGGER snippet_revision_update ON snippets;
+DROP TRIGGER snippet_revision_insert ON snippets;
+DROP FUNCTION record_snippet_revision();
+ALTER TABLE snippet_revisions ADD COLUMN files JSONB NOT NULL DEFAULT '[]';
+UPDATE snippet_revisions SET files = jsonb_build_array(jsonb_build_object(
+    'position', 0,
+    'file_name', 'snippet' || CASE languages.name
+        WHEN 'python' THEN '.py'
+        WHEN 'javascript' THEN '.js'
+        WHEN 'go' THEN '.go'
+        WHEN 'sql' THEN '.sql'
+        WHEN 'java' THEN '.java'
+        ELSE '.txt'
+    END,
+    'language_id', snippet_revisions.language_id,
+    'language', languages.name,
+    'file_text', snippet_revisions.snippet_text
+))
+FROM languages
+WHERE languages.id = snippet_revisions.language_id;
+
+CREATE OR REPLACE FUNCTION update_search_vector() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector := to_tsvector('simple', NEW.snippet_title || ' ' || NEW.snippet_description || ' ' || COALESCE(
+        (SELECT string_agg(file_name || ' ' || file_text, ' ' ORDER BY position) FROM snippet_files WHERE snippet_id = NEW.id),
+        NEW.snippet_text
+    ));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+UPDATE snippets SET search_vector = NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION update_search_vector() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector := to_tsvector('simple', NEW.snippet_title || ' ' || NEW.snippet_description || ' ' || NEW.snippet_text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+DROP TABLE snippet_files;
+UPDATE snippets SET search_vector = NULL;
+ALTER TABLE snippet_revisions DROP COLUMN files;
+CREATE OR REPLACE FUNCTION record_snippet_revision() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO snippet_revisions(id, snippet_id, revision, created_at, user_id, language_id, snippet_title, snippet_description, snippet_text)
+    VALUES(
+        gen_random_uuid(),
+        NEW.id,
+        (SELECT COALESCE(MAX(revision), 0) + 1 FROM snippet_revisions WHERE snippet_id = NEW.id),
+        NEW.updated_at,
+        NEW.user_id,
+        NEW.language_id,
+        NEW.snippet_title,
+        NEW.snippet_description,
+        NEW.snippet_text
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER snippet_revision_insert
+AFTER INSERT ON snippets
+FOR EACH ROW EXECUTE FUNCTION record_snippet_revision();
+CREATE TRIGGER snippet_revision_update
+AFTER UPDATE ON snippets
+FOR EACH ROW
+WHEN (
+    OLD.language_id IS DISTINCT FROM NEW.language_id
+    OR OLD.snippet_title IS DISTINCT FROM NEW.snippet_title
+    OR OLD.snippet_description IS DISTINCT FROM NEW.snippet_description
+    OR OLD.snippet_text IS DISTINCT FROM NEW.snippet_text
+)
+EXECUTE FUNCTION record_snippet_revision();
+-- +goose StatementEnd
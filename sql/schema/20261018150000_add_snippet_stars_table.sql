@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE snippet_stars(
+    user_id uuid NOT NULL,
+    snippet_id uuid NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, snippet_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_snippet_stars_snippet_id ON snippet_stars(snippet_id);
+CREATE INDEX idx_snippet_stars_user_id_created_at ON snippet_stars(user_id, created_at DESC);
+
+-- star_count is kept on snippets so listings can sort by it without counting
+ALTER TABLE snippets ADD COLUMN star_count INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX idx_snippets_star_count_created_at ON snippets(star_count DESC, created_at DESC);
+
+CREATE FUNCTION update_star_count() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE snippets SET star_count = star_count + 1 WHERE id = NEW.snippet_id;
+    ELSE
+        UPDATE snippets SET star_count = star_count - 1 WHERE id = OLD.snippet_id;
+    END IF;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER star_count_update
+AFTER INSERT OR DELETE ON snippet_stars
+FOR EACH ROW EXECUTE FUNCTION update_star_count();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER star_count_update ON snippet_stars;
+DROP FUNCTION update_star_count();
+DROP INDEX idx_snippets_star_count_created_at;
+ALTER TABLE snippets DROP COLUMN star_count;
+DROP TABLE snippet_stars;
+-- +goose StatementEnd
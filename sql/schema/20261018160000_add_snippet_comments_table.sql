@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE snippet_comments(
+    id uuid PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    snippet_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    parent_id uuid,
+    body TEXT NOT NULL,
+    deleted_at TIMESTAMP,
+    deleted_by uuid,
+    FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (parent_id) REFERENCES snippet_comments(id) ON DELETE CASCADE,
+    FOREIGN KEY (deleted_by) REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_snippet_comments_snippet_id_created_at ON snippet_comments(snippet_id, created_at);
+CREATE INDEX idx_snippet_comments_parent_id ON snippet_comments(parent_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE snippet_comments;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Line comments are comments anchored to a line range of one file in a
+-- revision. current_start_line and current_end_line track where that range
+-- is in the latest revision and are NULL once the lines have changed.
+ALTER TABLE snippet_comments
+    ADD COLUMN revision INTEGER,
+    ADD COLUMN file_name TEXT,
+    ADD COLUMN start_line INTEGER,
+    ADD COLUMN end_line INTEGER,
+    ADD COLUMN current_start_line INTEGER,
+    ADD COLUMN current_end_line INTEGER,
+    ADD COLUMN outdated BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD CONSTRAINT snippet_comments_anchor_check CHECK (
+        (revision IS NULL AND file_name IS NULL AND start_line IS NULL AND end_line IS NULL)
+        OR (revision IS NOT NULL AND file_name IS NOT NULL AND start_line >= 1 AND end_line >= start_line)
+    );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE snippet_comments
+    DROP CONSTRAINT snippet_comments_anchor_check,
+    DROP COLUMN outdated,
+    DROP COLUMN current_end_line,
+    DROP COLUMN current_start_line,
+    DROP COLUMN end_line,
+    DROP COLUMN start_line,
+    DROP COLUMN file_name,
+    DROP COLUMN revision;
+-- +goose StatementEnd
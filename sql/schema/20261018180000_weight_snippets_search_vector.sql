@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Weight matches so ranking prefers the title, then the description, then code
+CREATE OR REPLACE FUNCTION update_search_vector() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('simple', NEW.snippet_title), 'A') ||
+        setweight(to_tsvector('simple', NEW.snippet_description), 'B') ||
+        setweight(to_tsvector('simple', COALESCE(
+            (SELECT string_agg(file_name || ' ' || file_text, ' ' ORDER BY position) FROM snippet_files WHERE snippet_id = NEW.id),
+            NEW.snippet_text
+        )), 'C');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+UPDATE snippets SET search_vector = NULL;
+
+-- Search highlights wrap matches in <mark> tags, so the text around them is escaped first
+CREATE FUNCTION escape_html(input TEXT) RETURNS TEXT AS $$
+    SELECT replace(replace(replace(input, '&', '&amp;'), '<', '&lt;'), '>', '&gt;');
+$$ LANGUAGE sql IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION escape_html(TEXT);
+CREATE OR REPLACE FUNCTION update_search_vector() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector := to_tsvector('simple', NEW.snippet_title || ' ' || NEW.snippet_description || ' ' || COALESCE(
+        (SELECT string_agg(file_name || ' ' || file_text, ' ' ORDER BY position) FROM snippet_files WHERE snippet_id = NEW.id),
+        NEW.snippet_text
+    ));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+UPDATE snippets SET search_vector = NULL;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- code_vector holds identifiers, their camelCase, snake_case and dotted parts,
+-- and operators, as tokenized by the server. NULL means the snippet has not
+-- been tokenized yet; the server fills those in on startup.
+ALTER TABLE snippets ADD COLUMN code_vector tsvector;
+CREATE INDEX idx_snippets_search_code ON snippets USING gin((search_vector || COALESCE(code_vector, ''::tsvector)));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_snippets_search_code;
+ALTER TABLE snippets DROP COLUMN code_vector;
+-- +goose StatementEnd
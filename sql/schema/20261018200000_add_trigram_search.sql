@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX idx_snippets_title_trgm ON snippets USING gin(snippet_title gin_trgm_ops);
+CREATE INDEX idx_snippets_text_trgm ON snippets USING gin(snippet_text gin_trgm_ops);
+
+CREATE FUNCTION escape_like(input TEXT) RETURNS TEXT AS $$
+    SELECT replace(replace(replace(input, '\', '\\'), '%', '\%'), '_', '\_');
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Words in public snippets, used to suggest corrections for searches with no
+-- results. Refreshed periodically by the server.
+CREATE MATERIALIZED VIEW snippet_lexemes AS
+SELECT word, ndoc FROM ts_stat('SELECT search_vector FROM snippets WHERE visibility = ''public''');
+CREATE UNIQUE INDEX idx_snippet_lexemes_word ON snippet_lexemes(word);
+CREATE INDEX idx_snippet_lexemes_word_trgm ON snippet_lexemes USING gin(word gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP MATERIALIZED VIEW snippet_lexemes;
+DROP FUNCTION escape_like(TEXT);
+DROP INDEX idx_snippets_text_trgm;
+DROP INDEX idx_snippets_title_trgm;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Listings page by (sort key, created_at, id), so each order needs an index
+-- ending in the same columns
+DROP INDEX idx_snippets_visibility_created_at;
+DROP INDEX idx_snippets_star_count_created_at;
+CREATE INDEX idx_snippets_visibility_created_at_id ON snippets(visibility, created_at DESC, id DESC);
+CREATE INDEX idx_snippets_star_count_created_at_id ON snippets(star_count DESC, created_at DESC, id DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_snippets_star_count_created_at_id;
+DROP INDEX idx_snippets_visibility_created_at_id;
+CREATE INDEX idx_snippets_star_count_created_at ON snippets(star_count DESC, created_at DESC);
+CREATE INDEX idx_snippets_visibility_created_at ON snippets(visibility, created_at DESC);
+-- +goose StatementEnd
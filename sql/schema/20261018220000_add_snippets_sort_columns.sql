@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Counters and the search vector live on the same row, so only re-tokenize
+-- when the text changes, not on every star, view or fork
+DROP TRIGGER tsvector_update ON snippets;
+CREATE TRIGGER tsvector_update
+BEFORE INSERT OR UPDATE OF snippet_title, snippet_description, snippet_text, search_vector ON snippets
+FOR EACH ROW EXECUTE FUNCTION update_search_vector();
+
+-- fork_count counts public forks and is kept on snippets so listings can sort by it
+ALTER TABLE snippets ADD COLUMN fork_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE snippets ADD COLUMN view_count INTEGER NOT NULL DEFAULT 0;
+UPDATE snippets SET fork_count = (
+    SELECT COUNT(*) FROM snippets AS forks WHERE forks.forked_from_id = snippets.id AND forks.visibility = 'public'
+);
+
+CREATE FUNCTION update_fork_count() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'DELETE' AND NEW.forked_from_id IS NOT NULL THEN
+        UPDATE snippets SET fork_count = (
+            SELECT COUNT(*) FROM snippets AS forks WHERE forks.forked_from_id = NEW.forked_from_id AND forks.visibility = 'public'
+        ) WHERE id = NEW.forked_from_id;
+    END IF;
+    IF TG_OP <> 'INSERT' AND OLD.forked_from_id IS NOT NULL THEN
+        UPDATE snippets SET fork_count = (
+            SELECT COUNT(*) FROM snippets AS forks WHERE forks.forked_from_id = OLD.forked_from_id AND forks.visibility = 'public'
+        ) WHERE id = OLD.forked_from_id;
+    END IF;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER fork_count_update
+AFTER INSERT OR DELETE OR UPDATE OF visibility, forked_from_id ON snippets
+FOR EACH ROW EXECUTE FUNCTION update_fork_count();
+
+CREATE INDEX idx_snippets_updated_at_created_at_id ON snippets(updated_at DESC, created_at DESC, id DESC);
+CREATE INDEX idx_snippets_title_created_at_id ON snippets(snippet_title, created_at, id);
+CREATE INDEX idx_snippets_view_count_created_at_id ON snippets(view_count DESC, created_at DESC, id DESC);
+CREATE INDEX idx_snippets_fork_count_created_at_id ON snippets(fork_count DESC, created_at DESC, id DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_snippets_fork_count_created_at_id;
+DROP INDEX idx_snippets_view_count_created_at_id;
+DROP INDEX idx_snippets_title_created_at_id;
+DROP INDEX idx_snippets_updated_at_created_at_id;
+DROP TRIGGER fork_count_update ON snippets;
+DROP FUNCTION update_fork_count();
+ALTER TABLE snippets DROP COLUMN view_count;
+ALTER TABLE snippets DROP COLUMN fork_count;
+DROP TRIGGER tsvector_update ON snippets;
+CREATE TRIGGER tsvector_update
+BEFORE INSERT OR UPDATE ON snippets
+FOR EACH ROW EXECUTE FUNCTION update_search_vector();
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- query holds the GET /api/snippets query string the search was saved with.
+-- new_count is how many snippets matched since last_checked_at when last
+-- counted by the server.
+CREATE TABLE saved_searches(
+    id uuid PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id uuid NOT NULL,
+    name TEXT NOT NULL,
+    query TEXT NOT NULL,
+    last_checked_at TIMESTAMP NOT NULL,
+    new_count INTEGER NOT NULL DEFAULT 0,
+    counted_at TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (user_id, name)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE saved_searches;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Admins manage the language registry. Grant it with
+-- UPDATE users SET is_admin = true WHERE username = '...';
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN is_admin;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Extensions are listed with their leading dot, the first being the one used
+-- to name new files. A language without block comments has empty
+-- block_comment_start and block_comment_end.
+ALTER TABLE languages
+    ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN extensions TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN mime_type TEXT NOT NULL DEFAULT 'text/plain',
+    ADD COLUMN line_comment TEXT NOT NULL DEFAULT '',
+    ADD COLUMN block_comment_start TEXT NOT NULL DEFAULT '',
+    ADD COLUMN block_comment_end TEXT NOT NULL DEFAULT '';
+
+-- Every lowercase name a language can be given in, including its own name, so
+-- the primary key keeps names and aliases unique across languages
+CREATE TABLE language_aliases(
+    alias TEXT PRIMARY KEY,
+    language_id uuid NOT NULL,
+    FOREIGN KEY (language_id) REFERENCES languages(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_language_aliases_language_id ON language_aliases(language_id);
+
+UPDATE languages SET display_name = 'Python', extensions = '{.py,.pyw}', mime_type = 'text/x-python', line_comment = '#' WHERE name = 'python';
+UPDATE languages SET display_name = 'JavaScript', extensions = '{.js,.mjs,.cjs}', mime_type = 'text/javascript', line_comment = '//', block_comment_start = '/*', block_comment_end = '*/' WHERE name = 'javascript';
+UPDATE languages SET display_name = 'Go', extensions = '{.go}', mime_type = 'text/x-go', line_comment = '//', block_comment_start = '/*', block_comment_end = '*/' WHERE name = 'go';
+UPDATE languages SET display_name = 'SQL', extensions = '{.sql}', mime_type = 'application/sql', line_comment = '--', block_comment_start = '/*', block_comment_end = '*/' WHERE name = 'sql';
+UPDATE languages SET display_name = 'Java', extensions = '{.java}', mime_type = 'text/x-java', line_comment = '//', block_comment_start = '/*', block_comment_end = '*/' WHERE name = 'java';
+UPDATE languages SET display_name = 'Plain Text', extensions = '{.txt}', mime_type = 'text/plain' WHERE name = 'text';
+UPDATE languages SET display_name = name WHERE display_name = '';
+
+INSERT INTO language_aliases(alias, language_id)
+SELECT lower(languages.name), languages.id FROM languages;
+
+INSERT INTO language_aliases(alias, language_id)
+SELECT aliases.alias, languages.id
+FROM languages
+INNER JOIN (VALUES ('python', 'py'), ('python', 'python3'), ('javascript', 'js'), ('javascript', 'node'), ('go', 'golang'), ('text', 'txt'), ('text', 'plaintext')) AS aliases(name, alias)
+ON aliases.name = languages.name
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE language_aliases;
+ALTER TABLE languages
+    DROP COLUMN display_name,
+    DROP COLUMN extensions,
+    DROP COLUMN mime_type,
+    DROP COLUMN line_comment,
+    DROP COLUMN block_comment_start,
+    DROP COLUMN block_comment_end;
+-- +goose StatementEnd
@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The search vector of a snippet covers its files, falling back to its own
+-- text until they are written
+CREATE FUNCTION snippet_search_vector(snippet_id uuid, title TEXT, description TEXT, snippet_text TEXT) RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('simple', title), 'A') ||
+        setweight(to_tsvector('simple', description), 'B') ||
+        setweight(to_tsvector('simple', COALESCE(
+            (SELECT string_agg(file_name || ' ' || file_text, ' ' ORDER BY position) FROM snippet_files WHERE snippet_files.snippet_id = $1),
+            snippet_text
+        )), 'C');
+$$ LANGUAGE sql STABLE;
+CREATE OR REPLACE FUNCTION update_search_vector() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector := snippet_search_vector(NEW.id, NEW.snippet_title, NEW.snippet_description, NEW.snippet_text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- Once a snippet's files are written its vector is set explicitly, so
+-- setting the vector alone no longer fires the trigger
+DROP TRIGGER tsvector_update ON snippets;
+CREATE TRIGGER tsvector_update
+BEFORE INSERT OR UPDATE OF snippet_title, snippet_description, snippet_text ON snippets
+FOR EACH ROW EXECUTE FUNCTION update_search_vector();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER tsvector_update ON snippets;
+CREATE TRIGGER tsvector_update
+BEFORE INSERT OR UPDATE OF snippet_title, snippet_description, snippet_text, search_vector ON snippets
+FOR EACH ROW EXECUTE FUNCTION update_search_vector();
+CREATE OR REPLACE FUNCTION update_search_vector() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('simple', NEW.snippet_title), 'A') ||
+        setweight(to_tsvector('simple', NEW.snippet_description), 'B') ||
+        setweight(to_tsvector('simple', COALESCE(
+            (SELECT string_agg(file_name || ' ' || file_text, ' ' ORDER BY position) FROM snippet_files WHERE snippet_id = NEW.id),
+            NEW.snippet_text
+        )), 'C');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+DROP FUNCTION snippet_search_vector(uuid, TEXT, TEXT, TEXT);
+-- +goose StatementEnd
@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The filters of a snippet listing, shared by the listing, its count and its
+-- facets so they always agree. A SQL function like this is inlined into the
+-- query calling it, so the planner still sees each filter and its indexes.
+-- Parameters are qualified with the function name where a table or column
+-- has the same name.
+CREATE FUNCTION filter_snippets(
+    owner_id uuid,
+    languages TEXT[],
+    usernames TEXT[],
+    created_after TIMESTAMP,
+    created_before TIMESTAMP,
+    updated_since TIMESTAMP,
+    has_forks BOOLEAN,
+    min_stars INTEGER,
+    search tsquery,
+    fuzzy TEXT,
+    substring_text TEXT,
+    regex_literal TEXT,
+    tags TEXT[],
+    match_all_tags BOOLEAN
+) RETURNS SETOF snippets AS $$
+    SELECT snippets.*
+    FROM snippets
+    INNER JOIN languages ON snippets.language_id = languages.id
+    INNER JOIN users ON snippets.user_id = users.id
+    WHERE
+    ((filter_snippets.owner_id IS NULL AND snippets.visibility = 'public') OR snippets.user_id = filter_snippets.owner_id)
+    AND (filter_snippets.languages IS NULL OR languages.name = ANY(filter_snippets.languages))
+    AND (filter_snippets.usernames IS NULL OR users.username = ANY(filter_snippets.usernames))
+    AND (filter_snippets.created_after IS NULL OR snippets.created_at >= filter_snippets.created_after)
+    AND (filter_snippets.created_before IS NULL OR snippets.created_at < filter_snippets.created_before)
+    AND (filter_snippets.updated_since IS NULL OR snippets.updated_at >= filter_snippets.updated_since)
+    AND (filter_snippets.has_forks IS NULL OR (filter_snippets.has_forks AND snippets.fork_count > 0) OR (NOT filter_snippets.has_forks AND snippets.fork_count = 0))
+    AND (filter_snippets.min_stars IS NULL OR snippets.star_count >= filter_snippets.min_stars)
+    AND (filter_snippets.search IS NULL OR (snippets.search_vector || COALESCE(snippets.code_vector, ''::tsvector)) @@ filter_snippets.search)
+    AND (filter_snippets.fuzzy IS NULL OR snippets.snippet_title % filter_snippets.fuzzy OR filter_snippets.fuzzy <% snippets.snippet_title OR filter_snippets.fuzzy <% snippets.snippet_text)
+    AND (filter_snippets.substring_text IS NULL OR snippets.snippet_title ILIKE '%' || escape_like(filter_snippets.substring_text) || '%' OR snippets.snippet_text ILIKE '%' || escape_like(filter_snippets.substring_text) || '%')
+    AND (filter_snippets.regex_literal IS NULL OR snippets.snippet_text ILIKE '%' || escape_like(filter_snippets.regex_literal) || '%')
+    AND (filter_snippets.tags IS NULL OR (
+        SELECT COUNT(*)
+        FROM snippet_tags
+        INNER JOIN tags ON tags.id = snippet_tags.tag_id
+        WHERE snippet_tags.snippet_id = snippets.id
+        AND tags.name = ANY(filter_snippets.tags)
+    ) >= CASE WHEN filter_snippets.match_all_tags THEN cardinality(filter_snippets.tags) ELSE 1 END);
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION filter_snippets(uuid, TEXT[], TEXT[], TIMESTAMP, TIMESTAMP, TIMESTAMP, BOOLEAN, INTEGER, tsquery, TEXT, TEXT, TEXT, TEXT[], BOOLEAN);
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Files whose language was detected rather than chosen are left out of the
+-- samples the detector is trained on, so it doesn't learn from its own guesses.
+ALTER TABLE snippet_files ADD COLUMN language_detected BOOLEAN NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE snippet_files DROP COLUMN language_detected;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- New revisions record whether each file's language was detected. Older
+-- revisions get the flag too, so the snapshot of an unchanged snippet still
+-- equals its latest revision and no duplicate revision is recorded.
+UPDATE snippet_revisions SET files = (
+    SELECT jsonb_agg(jsonb_build_object('language_detected', false) || revision_files.file ORDER BY revision_files.ordinality)
+    FROM jsonb_array_elements(snippet_revisions.files) WITH ORDINALITY AS revision_files(file, ordinality)
+)
+WHERE jsonb_array_length(snippet_revisions.files) > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE snippet_revisions SET files = (
+    SELECT jsonb_agg(revision_files.file - 'language_detected' ORDER BY revision_files.ordinality)
+    FROM jsonb_array_elements(snippet_revisions.files) WITH ORDINALITY AS revision_files(file, ordinality)
+)
+WHERE jsonb_array_length(snippet_revisions.files) > 0;
+-- +goose StatementEnd